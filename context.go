@@ -0,0 +1,55 @@
+package zlog
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+)
+
+type contextAttrsKey struct{}
+
+type contextLoggerKey struct{}
+
+// NewContext returns a new context that carries the attributes, args are converted
+// to attributes like Logger.With. The attributes are appended to the ones carried
+// by ctx. JSONHandler and TextHandler add the attributes to the records logged
+// with the context, without a ContextExtractor.
+//
+//	ctx = zlog.NewContext(ctx, "request_id", id)
+//	log.InfoContext(ctx, "user login") // {"msg":"user login","request_id":"..."}
+func NewContext(ctx context.Context, args ...any) context.Context {
+	attrs := argsToAttrs(args...)
+	if len(attrs) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, contextAttrsKey{}, append(slices.Clip(ContextAttrs(ctx)), attrs...))
+}
+
+// ContextAttrs returns the attributes carried by the context, which are added by NewContext.
+// Handlers can call it to add the attributes to the records.
+func ContextAttrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(contextAttrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// NewLoggerContext returns a new context that carries the logger, see FromContext.
+func NewLoggerContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextLoggerKey{}, l)
+}
+
+// FromContext returns the logger carried by the context, or the default logger if
+// there is none or ctx is nil. The returned logger is bound to ctx by Logger.WithContext,
+// so the attributes of NewContext are added to its logs even by the methods without a context.
+func FromContext(ctx context.Context) *Logger {
+	if ctx == nil {
+		return defaultLogger
+	}
+	l, _ := ctx.Value(contextLoggerKey{}).(*Logger)
+	if l == nil {
+		l = defaultLogger
+	}
+	return l.WithContext(ctx)
+}
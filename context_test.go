@@ -0,0 +1,125 @@
+package zlog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestContextAttrs(t *testing.T) {
+	ctx := context.Background()
+	if attrs := ContextAttrs(ctx); attrs != nil {
+		t.Errorf("got %v, want nil", attrs)
+	}
+	if NewContext(ctx) != ctx {
+		t.Error("NewContext without attrs should return ctx")
+	}
+
+	ctx1 := NewContext(ctx, "a", 1)
+	ctx2 := NewContext(ctx1, slog.String("b", "2"))
+	ctx3 := NewContext(ctx1, "c", 3)
+	check := func(ctx context.Context, expected string) {
+		t.Helper()
+		var keys []string
+		for _, a := range ContextAttrs(ctx) {
+			keys = append(keys, a.Key)
+		}
+		if got := strings.Join(keys, ","); got != expected {
+			t.Errorf("got %s, want %s", got, expected)
+		}
+	}
+	check(ctx1, "a")
+	check(ctx2, "a,b")
+	check(ctx3, "a,c")
+}
+
+func TestHandlerContextAttrsFromNewContext(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	h := NewJSONHandler(&Config{
+		HandlerOptions: slog.HandlerOptions{
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		},
+		Writer:            buf,
+		ContextExtractors: []ContextExtractor{userContextExtractor},
+	})
+	ctx := NewContext(context.Background(), "request_id", "abc")
+	ctx = context.WithValue(ctx, userKey{}, user{Name: "john", Id: "1"})
+
+	tests := []struct {
+		name     string
+		h        slog.Handler
+		expected string
+	}{
+		{
+			name:     "json",
+			h:        h,
+			expected: `{"level":"INFO","msg":"test","request_id":"abc","user":{"name":"john","id":"1"},"k":"v"}`,
+		}, {
+			name:     "group",
+			h:        h.WithGroup("g"),
+			expected: `{"level":"INFO","msg":"test","g":{"request_id":"abc","user":{"name":"john","id":"1"},"k":"v"}}`,
+		}, {
+			name:     "text",
+			h:        NewTextHandler(h.c),
+			expected: `level=INFO msg=test request_id=abc user.name=john user.id=1 k=v`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf.Reset()
+			slog.New(test.h).InfoContext(ctx, "test", "k", "v")
+			if got := strings.TrimSuffix(buf.String(), "\n"); got != test.expected {
+				t.Errorf("got %s, want %s", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := New(NewTextHandler(&Config{
+		HandlerOptions: slog.HandlerOptions{
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		},
+		Writer: buf,
+	}))
+	ctx := NewContext(context.Background(), "request_id", "abc")
+
+	if got := FromContext(ctx).Handler(); got != defaultLogger.Handler() {
+		t.Error("want the default logger")
+	}
+	if got := FromContext(nil); got != defaultLogger {
+		t.Error("want the default logger for nil context")
+	}
+
+	ctx = NewLoggerContext(ctx, l)
+	log := FromContext(ctx)
+	log.Info("info")
+	log.Warnf("warn %d", 1)
+	log.LogAttrs(nil, slog.LevelError, "error")
+	// explicit context is used instead of the bound one
+	log.InfoContext(context.Background(), "background")
+	log.With("k", "v").Named("sub").Info("with")
+
+	expected := `level=INFO msg=info request_id=abc
+level=WARN msg="warn 1" request_id=abc
+level=ERROR msg=error request_id=abc
+level=INFO msg=background
+level=INFO logger=sub msg=with k=v request_id=abc
+`
+	if got := buf.String(); got != expected {
+		t.Errorf("got %s, want %s", got, expected)
+	}
+}
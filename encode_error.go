@@ -0,0 +1,166 @@
+package zlog
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+
+	"github.com/icefed/zlog/buffer"
+)
+
+// maxErrorChainLength limits the errors in the chain, in case of cyclic wrapping.
+const maxErrorChainLength = 32
+
+// addRichError encodes the error as a JSON object in the rich error mode, e.g.
+//
+//	{"msg":"read config: open app.yaml: no such file or directory","type":"*fmt.wrapError",
+//	"chain":[{"msg":"open app.yaml: no such file or directory","type":"*fs.PathError"},
+//	{"msg":"no such file or directory","type":"syscall.Errno"}]}
+//
+// "chain" is the errors wrapped by err in depth-first order, both errors.Unwrap and
+// Unwrap() []error of errors.Join are followed. "stack" is the stack trace of the
+// innermost error that has one, and "errorVerbose" is the output of "%+v" if err
+// implements fmt.Formatter, like pkg/errors.
+func (enc *jsonEncoder) addRichError(err error) {
+	enc.buf.WriteByte('{')
+	enc.addKey("msg")
+	enc.safeAddString(err.Error())
+	enc.addKey("type")
+	enc.safeAddString(reflect.TypeOf(err).String())
+
+	chain := errorChain(err)
+	if len(chain) > 0 {
+		enc.addKey("chain")
+		enc.buf.WriteByte('[')
+		for i, e := range chain {
+			if i > 0 {
+				enc.buf.WriteByte(',')
+			}
+			enc.buf.WriteByte('{')
+			enc.addKey("msg")
+			enc.safeAddString(e.Error())
+			enc.addKey("type")
+			enc.safeAddString(reflect.TypeOf(e).String())
+			enc.buf.WriteByte('}')
+		}
+		enc.buf.WriteByte(']')
+	}
+
+	// the innermost stack trace is the closest to the origin of the error
+	for i := len(chain) - 1; i >= -1; i-- {
+		e := err
+		if i >= 0 {
+			e = chain[i]
+		}
+		if frames := errorStackFrames(e); len(frames) > 0 {
+			buf := buffer.New()
+			formatFrames(buf, frames)
+			enc.addKey("stack")
+			enc.safeAddString(buf.String())
+			buf.Free()
+			break
+		}
+	}
+
+	if _, ok := err.(fmt.Formatter); ok {
+		buf := buffer.New()
+		*buf = fmt.Appendf(*buf, "%+v", err)
+		enc.addKey("errorVerbose")
+		enc.safeAddString(buf.String())
+		buf.Free()
+	}
+	enc.buf.WriteByte('}')
+}
+
+// errorChain returns the errors wrapped by err in depth-first order.
+func errorChain(err error) []error {
+	var chain []error
+	var walk func(err error)
+	walk = func(err error) {
+		var wrapped []error
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			wrapped = []error{e.Unwrap()}
+		case interface{ Unwrap() []error }:
+			wrapped = e.Unwrap()
+		}
+		for _, e := range wrapped {
+			if isNil(e) || len(chain) >= maxErrorChainLength {
+				continue
+			}
+			chain = append(chain, e)
+			walk(e)
+		}
+	}
+	walk(err)
+	return chain
+}
+
+// errorFramer is implemented by errors that expose the frames of their stack trace.
+type errorFramer interface {
+	Frames() []runtime.Frame
+}
+
+// errorCallers is implemented by errors that expose the pcs of their stack trace, like go-errors.
+type errorCallers interface {
+	Callers() []uintptr
+}
+
+// errorStackFrames returns the stack trace frames of the error, if it implements
+// errorFramer, errorCallers, or has a StackTrace method that returns a slice of pcs,
+// e.g. StackTrace() errors.StackTrace of pkg/errors.
+func errorStackFrames(err error) []runtime.Frame {
+	switch e := err.(type) {
+	case errorFramer:
+		return e.Frames()
+	case errorCallers:
+		return callersFrames(e.Callers())
+	}
+
+	// StackTrace() T, T is a slice of uintptr kind, like pkg/errors.StackTrace
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() {
+		return nil
+	}
+	mt := m.Type()
+	if mt.NumIn() != 0 || mt.NumOut() != 1 || mt.Out(0).Kind() != reflect.Slice || mt.Out(0).Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+	st := m.Call(nil)[0]
+	pcs := make([]uintptr, st.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(st.Index(i).Uint())
+	}
+	return callersFrames(pcs)
+}
+
+func callersFrames(pcs []uintptr) []runtime.Frame {
+	if len(pcs) == 0 {
+		return nil
+	}
+	var frames []runtime.Frame
+	fs := runtime.CallersFrames(pcs)
+	for {
+		f, more := fs.Next()
+		frames = append(frames, f)
+		if !more {
+			break
+		}
+	}
+	return frames
+}
+
+// formatFrames formats the frames like formatStacktrace.
+func formatFrames(buf *buffer.Buffer, frames []runtime.Frame) {
+	for i, f := range frames {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(f.Function)
+		buf.WriteString("\n\t")
+		buf.WriteString(f.File)
+		buf.WriteByte(':')
+		*buf = strconv.AppendInt(*buf, int64(f.Line), 10)
+	}
+}
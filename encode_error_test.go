@@ -0,0 +1,138 @@
+package zlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// stackError is like the errors of pkg/errors.
+type stackError struct {
+	msg string
+	pcs []uintptr
+}
+
+type stackTrace []uintptr
+
+func newStackError(msg string) *stackError {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	return &stackError{msg: msg, pcs: pcs[:n]}
+}
+
+func (e *stackError) Error() string { return e.msg }
+
+func (e *stackError) StackTrace() stackTrace { return e.pcs }
+
+func (e *stackError) Format(s fmt.State, verb rune) {
+	io.WriteString(s, e.msg)
+	if s.Flag('+') {
+		io.WriteString(s, "\nverbose")
+	}
+}
+
+type framesError struct{}
+
+func (framesError) Error() string { return "frames" }
+
+func (framesError) Frames() []runtime.Frame {
+	return []runtime.Frame{{Function: "main.main", File: "main.go", Line: 10}}
+}
+
+func TestRichErrors(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	h := NewJSONHandler(&Config{
+		HandlerOptions: slog.HandlerOptions{
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		},
+		Writer:     buf,
+		RichErrors: true,
+	})
+
+	base := errors.New("base")
+	tests := []struct {
+		name     string
+		err      any
+		expected string
+	}{
+		{
+			name:     "plain",
+			err:      base,
+			expected: `{"msg":"base","type":"*errors.errorString"}`,
+		}, {
+			name:     "wrapped",
+			err:      fmt.Errorf("outer: %w", fmt.Errorf("inner: %w", base)),
+			expected: `{"msg":"outer: inner: base","type":"*fmt.wrapError","chain":[{"msg":"inner: base","type":"*fmt.wrapError"},{"msg":"base","type":"*errors.errorString"}]}`,
+		}, {
+			name:     "joined",
+			err:      errors.Join(fmt.Errorf("a: %w", base), errors.New("b")),
+			expected: `{"msg":"a: base\nb","type":"*errors.joinError","chain":[{"msg":"a: base","type":"*fmt.wrapError"},{"msg":"base","type":"*errors.errorString"},{"msg":"b","type":"*errors.errorString"}]}`,
+		}, {
+			name:     "frames",
+			err:      fmt.Errorf("wrap: %w", framesError{}),
+			expected: `{"msg":"wrap: frames","type":"*fmt.wrapError","chain":[{"msg":"frames","type":"zlog.framesError"}],"stack":"main.main\n\tmain.go:10"}`,
+		}, {
+			name:     "error array",
+			err:      []error{base, nil},
+			expected: `[{"msg":"base","type":"*errors.errorString"},null]`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf.Reset()
+			slog.New(h).Info("test", "error", test.err)
+			expected := `{"level":"INFO","msg":"test","error":` + test.expected + "}\n"
+			if got := buf.String(); got != expected {
+				t.Errorf("got %s, want %s", got, expected)
+			}
+		})
+	}
+
+	t.Run("stack trace", func(t *testing.T) {
+		buf.Reset()
+		slog.New(h).Info("test", "error", fmt.Errorf("wrap: %w", newStackError("origin")))
+		var m struct {
+			Error struct {
+				Msg          string
+				Stack        string
+				ErrorVerbose *string
+			}
+		}
+		if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(m.Error.Stack, "github.com/icefed/zlog.TestRichErrors") {
+			t.Errorf("unexpected stack %q", m.Error.Stack)
+		}
+		// the outer error does not implement fmt.Formatter
+		if m.Error.ErrorVerbose != nil {
+			t.Errorf("unexpected errorVerbose %q", *m.Error.ErrorVerbose)
+		}
+
+		buf.Reset()
+		slog.New(h).Info("test", "error", newStackError("origin"))
+		if !strings.Contains(buf.String(), `"errorVerbose":"origin\nverbose"`) {
+			t.Errorf("errorVerbose not found in %s", buf.String())
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		buf.Reset()
+		slog.New(h.WithOptions(WithRichErrors(false))).Info("test", "error", fmt.Errorf("a: %w", base))
+		expected := `{"level":"INFO","msg":"test","error":"a: base"}` + "\n"
+		if got := buf.String(); got != expected {
+			t.Errorf("got %s, want %s", got, expected)
+		}
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log/slog"
 	"reflect"
+	"runtime"
 	"strconv"
 	"time"
 	"unicode"
@@ -20,17 +21,35 @@ type jsonEncoder struct {
 	timeFormatter     func([]byte, time.Time) []byte
 	timeDurationAsInt bool
 	ignoreEmptyGroup  bool
+	levelNames        LevelNames
+	lowercaseLevel    bool
+	redactor          *Redactor
+	richErrors        bool
+	callerFormat      CallerFormat
+	sourceObject      *SourceObject
+	keyMapping        KeyMapping
 	replaceAttr       func(groups []string, a slog.Attr) slog.Attr
 	openGroups        []string
 }
 
 func newJSONEncoder(h *JSONHandler, buf *buffer.Buffer) *jsonEncoder {
+	sourceObject := h.c.SourceObject
+	if h.c.ValueMapping.SourceAsString {
+		sourceObject = nil
+	}
 	return &jsonEncoder{
 		buf: buf,
 
 		timeFormatter:     h.c.TimeFormatter,
 		timeDurationAsInt: h.c.TimeDurationAsInt,
 		ignoreEmptyGroup:  h.c.IgnoreEmptyGroup,
+		levelNames:        h.c.LevelNames,
+		lowercaseLevel:    h.c.ValueMapping.LowercaseLevel,
+		redactor:          h.c.Redactor,
+		richErrors:        h.c.RichErrors,
+		callerFormat:      h.c.CallerFormat,
+		sourceObject:      sourceObject,
+		keyMapping:        h.c.KeyMapping,
 		openGroups:        h.groups,
 		replaceAttr:       h.c.ReplaceAttr,
 	}
@@ -45,6 +64,9 @@ func (enc *jsonEncoder) AppendAttr(a slog.Attr) {
 			return
 		}
 	}
+	if enc.redactor != nil {
+		a, _ = enc.redactor.redactAttr(enc.openGroups, a)
+	}
 	enc.appendAttr(a)
 }
 
@@ -89,7 +111,11 @@ func (enc *jsonEncoder) replaceBuildInAttr(a slog.Attr) slog.Attr {
 }
 
 func (enc *jsonEncoder) AppendTime(key string, t time.Time) {
-	if enc.replaceAttr != nil {
+	key, ok, mapped := enc.keyMapping.mapKey(key)
+	if !ok {
+		return
+	}
+	if enc.replaceAttr != nil && !mapped {
 		attr := slog.Time(key, t)
 		newAttr := enc.replaceBuildInAttr(attr)
 		if attr.Equal(newAttr) {
@@ -105,16 +131,24 @@ func (enc *jsonEncoder) AppendTime(key string, t time.Time) {
 }
 
 func (enc *jsonEncoder) AppendLevel(key string, l slog.Level) {
-	if enc.replaceAttr != nil {
+	key, ok, mapped := enc.keyMapping.mapKey(key)
+	if !ok {
+		return
+	}
+	if enc.replaceAttr != nil && !mapped {
 		enc.appendAttr(enc.replaceBuildInAttr(slog.Any(key, l)))
 		return
 	}
 	enc.addKey(key)
-	enc.addString(l.String())
+	enc.addLevel(l)
 }
 
 func (enc *jsonEncoder) AppendMessage(key string, s string) {
-	if enc.replaceAttr != nil {
+	key, ok, mapped := enc.keyMapping.mapKey(key)
+	if !ok {
+		return
+	}
+	if enc.replaceAttr != nil && !mapped {
 		enc.appendAttr(enc.replaceBuildInAttr(slog.String(key, s)))
 		return
 	}
@@ -122,8 +156,25 @@ func (enc *jsonEncoder) AppendMessage(key string, s string) {
 	enc.safeAddString(s)
 }
 
+func (enc *jsonEncoder) AppendLoggerName(key string, name string) {
+	key, ok, mapped := enc.keyMapping.mapKey(key)
+	if !ok {
+		return
+	}
+	if enc.replaceAttr != nil && !mapped {
+		enc.appendAttr(enc.replaceBuildInAttr(slog.String(key, name)))
+		return
+	}
+	enc.addKey(key)
+	enc.safeAddString(name)
+}
+
 func (enc *jsonEncoder) AppendSourceFromPC(key string, pc uintptr) {
-	if enc.replaceAttr != nil {
+	key, ok, mapped := enc.keyMapping.mapKey(key)
+	if !ok {
+		return
+	}
+	if enc.replaceAttr != nil && !mapped {
 		enc.appendAttr(enc.replaceBuildInAttr(slog.Any(key, buildSource(pc))))
 		return
 	}
@@ -131,6 +182,20 @@ func (enc *jsonEncoder) AppendSourceFromPC(key string, pc uintptr) {
 	enc.addSourceFromPC(pc)
 }
 
+func (enc *jsonEncoder) AppendFunction(key string, pc uintptr) {
+	key, ok, mapped := enc.keyMapping.mapKey(key)
+	if !ok {
+		return
+	}
+	function := funcNameFromPC(pc)
+	if enc.replaceAttr != nil && !mapped {
+		enc.appendAttr(enc.replaceBuildInAttr(slog.String(key, function)))
+		return
+	}
+	enc.addKey(key)
+	enc.safeAddString(function)
+}
+
 func (enc *jsonEncoder) AppendFormatted(formatted []byte) {
 	if len(formatted) == 0 {
 		return
@@ -140,7 +205,11 @@ func (enc *jsonEncoder) AppendFormatted(formatted []byte) {
 }
 
 func (enc *jsonEncoder) AppendStacktrace(key string, st *stacktrace) {
-	if enc.replaceAttr != nil {
+	key, ok, mapped := enc.keyMapping.mapKey(key)
+	if !ok {
+		return
+	}
+	if enc.replaceAttr != nil && !mapped {
 		enc.AppendAttr(slog.Any(key, st))
 		return
 	}
@@ -203,6 +272,8 @@ func (enc *jsonEncoder) addValue(v slog.Value) {
 
 func (enc *jsonEncoder) addAny(v any) {
 	switch v := v.(type) {
+	case slog.Level: // level
+		enc.addLevel(v)
 	case *slog.Source: // source
 		if isNil(v) {
 			enc.addNil()
@@ -377,6 +448,10 @@ func (enc *jsonEncoder) addAny(v any) {
 			enc.addNil()
 			return
 		}
+		if enc.richErrors {
+			enc.addRichError(v)
+			return
+		}
 		enc.safeAddString(v.Error())
 	default:
 		je := json.NewEncoder(&ioWriter{enc.buf})
@@ -408,6 +483,8 @@ func (enc *jsonEncoder) addErrorArray(errors []error) {
 		}
 		if isNil(err) {
 			enc.addNil()
+		} else if enc.richErrors {
+			enc.addRichError(err)
 		} else {
 			enc.safeAddString(err.Error())
 		}
@@ -667,26 +744,97 @@ func (enc *jsonEncoder) addTimeArray(arr []time.Time) {
 }
 
 func (enc *jsonEncoder) addSource(s *slog.Source) {
+	if enc.sourceObject != nil {
+		enc.addSourceObject(s.Function, s.File, s.Line)
+		return
+	}
 	enc.buf.WriteByte('"')
-	formatSourceValue(enc.buf, s)
+	formatSourceValue(enc.buf, enc.callerFormat, s)
 	enc.buf.WriteByte('"')
 }
 
 func (enc *jsonEncoder) addSourceFromPC(pc uintptr) {
+	if enc.sourceObject != nil {
+		fs := runtime.CallersFrames([]uintptr{pc})
+		f, _ := fs.Next()
+		enc.addSourceObject(f.Function, f.File, f.Line)
+		return
+	}
 	enc.buf.WriteByte('"')
-	formatSourceValueFromPC(enc.buf, pc)
+	formatSourceValueFromPC(enc.buf, enc.callerFormat, pc)
 	enc.buf.WriteByte('"')
 }
 
+// addSourceObject writes the source as an object with the keys of enc.sourceObject.
+func (enc *jsonEncoder) addSourceObject(function, file string, line int) {
+	o := enc.sourceObject
+	enc.buf.WriteByte('{')
+	if o.FileKey != "" {
+		enc.addKey(o.FileKey)
+		enc.buf.WriteByte('"')
+		formatSourceFile(enc.buf, enc.callerFormat, function, file)
+		enc.buf.WriteByte('"')
+	}
+	if o.LineKey != "" {
+		enc.addKey(o.LineKey)
+		if o.LineAsString {
+			enc.buf.WriteByte('"')
+			enc.addInt64(int64(line))
+			enc.buf.WriteByte('"')
+		} else {
+			enc.addInt64(int64(line))
+		}
+	}
+	if o.FunctionKey != "" && function != "" {
+		enc.addKey(o.FunctionKey)
+		enc.safeAddString(function)
+	}
+	enc.buf.WriteByte('}')
+}
+
 func (enc *jsonEncoder) addStacktrace(st *stacktrace) {
+	if st.c != nil && st.c.StacktraceFrames {
+		enc.addStackFrames(st.frames())
+		return
+	}
 	buf := buffer.New()
 	defer buf.Free()
 
-	formatStacktrace(buf, st.pc)
+	formatStacktrace(buf, st)
 
 	enc.safeAddString(buf.String())
 }
 
+// addStackFrames writes the frames as an array of {"func","file","line"} objects,
+// "repeated" is added for the collapsed frames.
+func (enc *jsonEncoder) addStackFrames(frames []stackFrame) {
+	enc.buf.WriteByte('[')
+	for i, f := range frames {
+		if i > 0 {
+			enc.buf.WriteByte(',')
+		}
+		enc.buf.WriteByte('{')
+		enc.addKey("func")
+		enc.safeAddString(f.function)
+		enc.addKey("file")
+		enc.safeAddString(f.file)
+		enc.addKey("line")
+		enc.addInt64(int64(f.line))
+		if f.repeated > 0 {
+			enc.addKey("repeated")
+			enc.addInt64(int64(f.repeated))
+		}
+		enc.buf.WriteByte('}')
+	}
+	enc.buf.WriteByte(']')
+}
+
+func (enc *jsonEncoder) addLevel(l slog.Level) {
+	enc.buf.WriteByte('"')
+	*enc.buf = appendLevelName(*enc.buf, enc.levelNames, l, enc.lowercaseLevel)
+	enc.buf.WriteByte('"')
+}
+
 func (enc *jsonEncoder) addBool(b bool) {
 	*enc.buf = strconv.AppendBool(*enc.buf, b)
 }
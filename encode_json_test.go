@@ -75,7 +75,7 @@ func TestJSONEncoder(t *testing.T) {
 		}, {
 			name:  "stacktrace",
 			key:   "stacktrace",
-			value: &stacktrace{getPC()},
+			value: &stacktrace{pc: getPC()},
 			want:  `"stacktrace":"` + wantPCFunction + "\\n\\t" + wantPCFile + ":" + strconv.Itoa(wantPCLine) + `"`,
 		}, {
 			name:  "ip",
@@ -207,7 +207,7 @@ func TestJSONEncoder(t *testing.T) {
 		}, {
 			name:  "stacktrace",
 			key:   "stacktrace",
-			value: &stacktrace{getPC()},
+			value: &stacktrace{pc: getPC()},
 			want:  `"stacktrace":"` + wantPCFunction + "\\n\\t" + wantPCFile + ":" + strconv.Itoa(wantPCLine) + `"`,
 		}, {
 			name:  "group",
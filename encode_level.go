@@ -17,6 +17,8 @@ const (
 	magenta = "\033[35m"
 	cyan    = "\033[36m"
 	white   = "\033[37m"
+	boldRed = "\033[1;31m"
+	redBg   = "\033[41m"
 	reset   = "\033[0m"
 )
 
@@ -32,7 +34,7 @@ func init() {
 }
 
 // formatColorLevelValue returns the string representation of the level.
-func formatColorLevelValue(buf *buffer.Buffer, l slog.Level) {
+func formatColorLevelValue(buf *buffer.Buffer, l slog.Level, names LevelNames, lower bool) {
 	var mode lvlEscape
 	for _, mode = range levelColorList {
 		if l >= mode.Level {
@@ -40,13 +42,15 @@ func formatColorLevelValue(buf *buffer.Buffer, l slog.Level) {
 		}
 	}
 	buf.WriteString(mode.string)
-	buf.WriteString(l.String())
+	*buf = appendLevelName(*buf, names, l, lower)
 	buf.WriteString(reset)
 }
 
 // UseDefaultLevelColors resets  the colors levels to the default configuration
 func UseDefaultLevelColors() {
 	levelColorList = []lvlEscape{
+		{LevelFatal, redBg},
+		{LevelPanic, boldRed},
 		{slog.LevelError, red},
 		{slog.LevelWarn, yellow},
 		{slog.LevelInfo, blue},
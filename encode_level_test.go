@@ -43,10 +43,22 @@ func TestFormatColorLevelValue(t *testing.T) {
 			name:  "error",
 			level: slog.LevelError,
 			want:  []byte("\033[31mERROR\033[0m"),
+		}, {
+			name:  "critical",
+			level: LevelCritical,
+			want:  []byte("\033[31mCRITICAL\033[0m"),
+		}, {
+			name:  "panic",
+			level: LevelPanic,
+			want:  []byte("\033[1;31mPANIC\033[0m"),
+		}, {
+			name:  "fatal",
+			level: LevelFatal,
+			want:  []byte("\033[41mFATAL\033[0m"),
 		}, {
 			name:  "error+100",
 			level: slog.LevelError + 100,
-			want:  []byte("\033[31mERROR+100\033[0m"),
+			want:  []byte("\033[41mERROR+100\033[0m"),
 		},
 	}
 
@@ -55,7 +67,7 @@ func TestFormatColorLevelValue(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			formatColorLevelValue(buf, test.level)
+			formatColorLevelValue(buf, test.level, nil, false)
 			if !bytes.Equal(buf.Bytes(), test.want) {
 				t.Errorf("got %v, want %v", string(buf.Bytes()), string(test.want))
 			}
@@ -76,6 +88,8 @@ func TestSetLevelColor(t *testing.T) {
 			val:  slog.LevelError + 100,
 			want: []slog.Level{
 				slog.LevelError + 100,
+				LevelFatal,
+				LevelPanic,
 				slog.LevelError,
 				slog.LevelWarn,
 				slog.LevelInfo,
@@ -86,6 +100,8 @@ func TestSetLevelColor(t *testing.T) {
 			name: "replace error",
 			val:  slog.LevelError,
 			want: []slog.Level{
+				LevelFatal,
+				LevelPanic,
 				slog.LevelError,
 				slog.LevelWarn,
 				slog.LevelInfo,
@@ -96,6 +112,8 @@ func TestSetLevelColor(t *testing.T) {
 			name: "in middle",
 			val:  slog.LevelError - 2,
 			want: []slog.Level{
+				LevelFatal,
+				LevelPanic,
 				slog.LevelError,
 				slog.LevelError - 2,
 				slog.LevelWarn,
@@ -107,6 +125,8 @@ func TestSetLevelColor(t *testing.T) {
 			name: "below debug",
 			val:  slog.LevelDebug - 4,
 			want: []slog.Level{
+				LevelFatal,
+				LevelPanic,
 				slog.LevelError,
 				slog.LevelWarn,
 				slog.LevelInfo,
@@ -0,0 +1,386 @@
+package zlog
+
+import (
+	"encoding"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/icefed/zlog/buffer"
+)
+
+// logfmtEncoder encode attributes as key=value pairs for TextHandler,
+// keys of attributes in groups are qualified by the group names with dots.
+type logfmtEncoder struct {
+	buf *buffer.Buffer
+
+	timeFormatter     func([]byte, time.Time) []byte
+	timeDurationAsInt bool
+	levelNames        LevelNames
+	lowercaseLevel    bool
+	redactor          *Redactor
+	callerFormat      CallerFormat
+	keyMapping        KeyMapping
+	replaceAttr       func(groups []string, a slog.Attr) slog.Attr
+	openGroups        []string
+}
+
+func newLogfmtEncoder(h *JSONHandler, buf *buffer.Buffer) *logfmtEncoder {
+	return &logfmtEncoder{
+		buf: buf,
+
+		timeFormatter:     h.c.TimeFormatter,
+		timeDurationAsInt: h.c.TimeDurationAsInt,
+		levelNames:        h.c.LevelNames,
+		lowercaseLevel:    h.c.ValueMapping.LowercaseLevel,
+		redactor:          h.c.Redactor,
+		callerFormat:      h.c.CallerFormat,
+		keyMapping:        h.c.KeyMapping,
+		openGroups:        h.groups,
+		replaceAttr:       h.c.ReplaceAttr,
+	}
+}
+
+func (enc *logfmtEncoder) AppendAttr(a slog.Attr) {
+	if enc.replaceAttr != nil && a.Value.Kind() != slog.KindGroup {
+		a.Value = a.Value.Resolve()
+		a = enc.replaceAttr(enc.openGroups, a)
+		// If ReplaceAttr returns an Attr with Key == "", the attribute is discarded.
+		if a.Key == "" {
+			return
+		}
+	}
+	if enc.redactor != nil {
+		a, _ = enc.redactor.redactAttr(enc.openGroups, a)
+	}
+	enc.appendAttr(a)
+}
+
+func (enc *logfmtEncoder) appendAttr(a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	// If an Attr's key and value are both the zero value, ignore the Attr.
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		groupAttrs := a.Value.Group()
+		// If a group's key is empty, inline the group's Attrs.
+		if a.Key == "" {
+			for i := range groupAttrs {
+				enc.appendAttr(groupAttrs[i])
+			}
+			return
+		}
+		// Empty groups have nothing to write.
+		enc.OpenGroup(a.Key)
+		for i := range groupAttrs {
+			enc.appendAttr(groupAttrs[i])
+		}
+		enc.CloseGroup()
+		return
+	}
+
+	enc.addKey(a.Key)
+	enc.addValue(a.Value)
+}
+
+func (enc *logfmtEncoder) replaceBuildInAttr(a slog.Attr) slog.Attr {
+	newAttr := enc.replaceAttr(nil, a)
+	// If ReplaceAttr returns an Attr with Key == "", the attribute is discarded.
+	if newAttr.Key == "" {
+		return slog.Attr{}
+	}
+	return newAttr
+}
+
+// appendBuildInAttr appends a replaced built-in attribute, which is not in any group.
+func (enc *logfmtEncoder) appendBuildInAttr(a slog.Attr) {
+	groups := enc.openGroups
+	enc.openGroups = nil
+	enc.appendAttr(a)
+	enc.openGroups = groups
+}
+
+func (enc *logfmtEncoder) AppendTime(key string, t time.Time) {
+	key, ok, mapped := enc.keyMapping.mapKey(key)
+	if !ok {
+		return
+	}
+	if enc.replaceAttr != nil && !mapped {
+		attr := slog.Time(key, t)
+		newAttr := enc.replaceBuildInAttr(attr)
+		if attr.Equal(newAttr) {
+			enc.addBuildInKey(newAttr.Key)
+			enc.addBuildInTime(newAttr.Value.Any().(time.Time))
+		} else {
+			enc.appendBuildInAttr(newAttr)
+		}
+		return
+	}
+	enc.addBuildInKey(key)
+	enc.addBuildInTime(t)
+}
+
+func (enc *logfmtEncoder) AppendLevel(key string, l slog.Level) {
+	key, ok, mapped := enc.keyMapping.mapKey(key)
+	if !ok {
+		return
+	}
+	if enc.replaceAttr != nil && !mapped {
+		enc.appendBuildInAttr(enc.replaceBuildInAttr(slog.Any(key, l)))
+		return
+	}
+	enc.addBuildInKey(key)
+	*enc.buf = appendLevelName(*enc.buf, enc.levelNames, l, enc.lowercaseLevel)
+}
+
+func (enc *logfmtEncoder) AppendMessage(key string, s string) {
+	key, ok, mapped := enc.keyMapping.mapKey(key)
+	if !ok {
+		return
+	}
+	if enc.replaceAttr != nil && !mapped {
+		enc.appendBuildInAttr(enc.replaceBuildInAttr(slog.String(key, s)))
+		return
+	}
+	enc.addBuildInKey(key)
+	enc.addString(s)
+}
+
+func (enc *logfmtEncoder) AppendLoggerName(key string, name string) {
+	key, ok, mapped := enc.keyMapping.mapKey(key)
+	if !ok {
+		return
+	}
+	if enc.replaceAttr != nil && !mapped {
+		enc.appendBuildInAttr(enc.replaceBuildInAttr(slog.String(key, name)))
+		return
+	}
+	enc.addBuildInKey(key)
+	enc.addString(name)
+}
+
+func (enc *logfmtEncoder) AppendSourceFromPC(key string, pc uintptr) {
+	key, ok, mapped := enc.keyMapping.mapKey(key)
+	if !ok {
+		return
+	}
+	if enc.replaceAttr != nil && !mapped {
+		enc.appendBuildInAttr(enc.replaceBuildInAttr(slog.Any(key, buildSource(pc))))
+		return
+	}
+	enc.addBuildInKey(key)
+	enc.addSourceFromPC(pc)
+}
+
+func (enc *logfmtEncoder) AppendFunction(key string, pc uintptr) {
+	key, ok, mapped := enc.keyMapping.mapKey(key)
+	if !ok {
+		return
+	}
+	function := funcNameFromPC(pc)
+	if enc.replaceAttr != nil && !mapped {
+		enc.appendBuildInAttr(enc.replaceBuildInAttr(slog.String(key, function)))
+		return
+	}
+	enc.addBuildInKey(key)
+	enc.addString(function)
+}
+
+func (enc *logfmtEncoder) AppendStacktrace(key string, st *stacktrace) {
+	key, ok, mapped := enc.keyMapping.mapKey(key)
+	if !ok {
+		return
+	}
+	if enc.replaceAttr != nil && !mapped {
+		enc.appendBuildInAttr(enc.replaceBuildInAttr(slog.Any(key, st)))
+		return
+	}
+	enc.addBuildInKey(key)
+	enc.addStacktrace(st)
+}
+
+func (enc *logfmtEncoder) AppendFormatted(formatted []byte) {
+	if len(formatted) == 0 {
+		return
+	}
+	enc.addSeparator()
+	enc.buf.Write(formatted)
+}
+
+func (enc *logfmtEncoder) OpenGroup(g string) {
+	enc.openGroups = append(enc.openGroups, g)
+}
+
+func (enc *logfmtEncoder) CloseGroup() {
+	if len(enc.openGroups) == 0 {
+		return
+	}
+	enc.openGroups = enc.openGroups[:len(enc.openGroups)-1]
+}
+
+// addValue handle slog.Value except slog.KindLogValuer and slog.KindGroup
+func (enc *logfmtEncoder) addValue(v slog.Value) {
+	switch v.Kind() {
+	case slog.KindAny:
+		enc.addAny(v.Any())
+	case slog.KindBool:
+		*enc.buf = strconv.AppendBool(*enc.buf, v.Bool())
+	case slog.KindDuration:
+		if enc.timeDurationAsInt {
+			*enc.buf = strconv.AppendInt(*enc.buf, int64(v.Duration()), 10)
+			return
+		}
+		enc.buf.WriteString(v.Duration().String())
+	case slog.KindFloat64:
+		*enc.buf = strconv.AppendFloat(*enc.buf, v.Float64(), 'f', -1, 64)
+	case slog.KindInt64:
+		*enc.buf = strconv.AppendInt(*enc.buf, v.Int64(), 10)
+	case slog.KindString:
+		enc.addString(v.String())
+	case slog.KindTime:
+		*enc.buf = v.Time().AppendFormat(*enc.buf, time.RFC3339Nano)
+	case slog.KindUint64:
+		*enc.buf = strconv.AppendUint(*enc.buf, v.Uint64(), 10)
+	default:
+		panic(fmt.Sprintf("bad kind: %s", v.Kind()))
+	}
+}
+
+func (enc *logfmtEncoder) addAny(v any) {
+	switch v := v.(type) {
+	case nil:
+		enc.buf.WriteString("<nil>")
+	case slog.Level:
+		*enc.buf = appendLevelName(*enc.buf, enc.levelNames, v, enc.lowercaseLevel)
+	case *slog.Source:
+		if v == nil {
+			enc.buf.WriteString("<nil>")
+			return
+		}
+		buf := buffer.New()
+		defer buf.Free()
+		formatSourceValue(buf, enc.callerFormat, v)
+		enc.addString(buf.String())
+	case *stacktrace:
+		if v == nil {
+			enc.buf.WriteString("<nil>")
+			return
+		}
+		enc.addStacktrace(v)
+	case []byte:
+		enc.addString((*buffer.Buffer)(&v).String())
+	case encoding.TextMarshaler:
+		if isNil(v) {
+			enc.buf.WriteString("<nil>")
+			return
+		}
+		data, err := v.MarshalText()
+		if err != nil {
+			enc.addString(fmt.Sprintf("!ERROR:%v", err))
+			return
+		}
+		enc.addString((*buffer.Buffer)(&data).String())
+	case error:
+		if isNil(v) {
+			enc.buf.WriteString("<nil>")
+			return
+		}
+		enc.addString(v.Error())
+	default:
+		buf := buffer.New()
+		defer buf.Free()
+		*buf = fmt.Appendf(*buf, "%+v", v)
+		enc.addString(buf.String())
+	}
+}
+
+func (enc *logfmtEncoder) addSourceFromPC(pc uintptr) {
+	buf := buffer.New()
+	defer buf.Free()
+	formatSourceValueFromPC(buf, enc.callerFormat, pc)
+	enc.addString(buf.String())
+}
+
+func (enc *logfmtEncoder) addStacktrace(st *stacktrace) {
+	buf := buffer.New()
+	defer buf.Free()
+	formatStacktrace(buf, st)
+	enc.addString(buf.String())
+}
+
+func (enc *logfmtEncoder) addBuildInTime(t time.Time) {
+	buf := buffer.New()
+	defer buf.Free()
+	*buf = enc.timeFormatter(*buf, t)
+	enc.addString(buf.String())
+}
+
+// addString writes s, quoted if it can not be used as a bare value.
+func (enc *logfmtEncoder) addString(s string) {
+	if needsQuoting(s) {
+		*enc.buf = strconv.AppendQuote(*enc.buf, s)
+		return
+	}
+	enc.buf.WriteString(s)
+}
+
+// addBuildInKey writes a key of the built-in attributes, which are not in any group.
+func (enc *logfmtEncoder) addBuildInKey(key string) {
+	enc.addSeparator()
+	enc.addString(key)
+	enc.buf.WriteByte('=')
+}
+
+// addKey writes the key qualified by the open groups.
+func (enc *logfmtEncoder) addKey(key string) {
+	enc.addSeparator()
+	if len(enc.openGroups) == 0 {
+		enc.addString(key)
+		enc.buf.WriteByte('=')
+		return
+	}
+
+	buf := buffer.New()
+	defer buf.Free()
+	for _, g := range enc.openGroups {
+		buf.WriteString(g)
+		buf.WriteByte('.')
+	}
+	buf.WriteString(key)
+	enc.addString(buf.String())
+	enc.buf.WriteByte('=')
+}
+
+func (enc *logfmtEncoder) addSeparator() {
+	if enc.buf.Len() == 0 || *enc.buf.LastByte() == ' ' {
+		return
+	}
+	enc.buf.WriteByte(' ')
+}
+
+// needsQuoting reports whether s must be quoted to be a logfmt key or value.
+func needsQuoting(s string) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for i := 0; i < len(s); {
+		b := s[i]
+		if b < utf8.RuneSelf {
+			if b == '=' || b == '"' || b == ' ' || !unicode.IsPrint(rune(b)) {
+				return true
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return true
+		}
+		i += size
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package zlog
+
+import "testing"
+
+func TestNeedsQuoting(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"", true},
+		{"value", false},
+		{"a.b-c_d/e:1", false},
+		{"héllo", false},
+		{"a b", true},
+		{"a=b", true},
+		{`a"b`, true},
+		{"a\nb", true},
+		{"a\tb", true},
+		{"a b", true},
+		{"\xff", true},
+	}
+	for _, test := range tests {
+		if got := needsQuoting(test.s); got != test.want {
+			t.Errorf("needsQuoting(%q) got %v, want %v", test.s, got, test.want)
+		}
+	}
+}
@@ -9,6 +9,46 @@ import (
 	"github.com/icefed/zlog/buffer"
 )
 
+// CallerFormat is the format of the source value.
+type CallerFormat int
+
+const (
+	// CallerShort formats the source as the last directory, the file name and the line,
+	// e.g. "zlog/handler.go:42", it is the default format.
+	CallerShort CallerFormat = iota
+	// CallerFull formats the source as the full file path and the line,
+	// e.g. "/home/user/go/src/zlog/handler.go:42".
+	CallerFull
+	// CallerPackage formats the source as the package path, the file name and the line,
+	// e.g. "github.com/icefed/zlog/handler.go:42".
+	CallerPackage
+	// CallerShortFunc formats the source as CallerShort followed by the function name
+	// without the package path, e.g. "zlog/handler.go:42 zlog.(*JSONHandler).Handle".
+	CallerShortFunc
+)
+
+// SourceObject defines the keys of the source fields, to write the source as an
+// object instead of a string in JSONHandler, e.g. {"file":"zlog/handler.go","line":42}.
+// The fields with empty keys are omitted, the file is formatted by Config.CallerFormat.
+type SourceObject struct {
+	// FileKey is the key of the file path field.
+	FileKey string
+	// LineKey is the key of the line number field.
+	LineKey string
+	// FunctionKey is the key of the full function name field.
+	FunctionKey string
+	// LineAsString writes the line number as a string, e.g. "42".
+	LineAsString bool
+}
+
+func (o *SourceObject) clone() *SourceObject {
+	if o == nil {
+		return nil
+	}
+	newObject := *o
+	return &newObject
+}
+
 func buildSource(pc uintptr) *slog.Source {
 	fs := runtime.CallersFrames([]uintptr{pc})
 	f, _ := fs.Next()
@@ -19,43 +59,101 @@ func buildSource(pc uintptr) *slog.Source {
 	}
 }
 
-func formatSourceValueFromPC(buf *buffer.Buffer, pc uintptr) {
+func formatSourceValueFromPC(buf *buffer.Buffer, format CallerFormat, pc uintptr) {
 	fs := runtime.CallersFrames([]uintptr{pc})
 	f, _ := fs.Next()
+	formatSource(buf, format, f.Function, f.File, f.Line)
+}
 
-	defer func() {
-		buf.WriteByte(':')
-		*buf = strconv.AppendInt(*buf, int64(f.Line), 10)
-	}()
+func formatSourceValue(buf *buffer.Buffer, format CallerFormat, s *slog.Source) {
+	formatSource(buf, format, s.Function, s.File, s.Line)
+}
 
-	i := strings.LastIndexByte(f.File, '/')
+func formatSource(buf *buffer.Buffer, format CallerFormat, function, file string, line int) {
+	formatSourceFile(buf, format, function, file)
+	buf.WriteByte(':')
+	*buf = strconv.AppendInt(*buf, int64(line), 10)
+	if format == CallerShortFunc && function != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(shortFunction(function))
+	}
+}
+
+// formatSourceFile writes the file path of the source in the format.
+func formatSourceFile(buf *buffer.Buffer, format CallerFormat, function, file string) {
+	switch format {
+	case CallerFull:
+		buf.WriteString(file)
+	case CallerPackage:
+		if pkg := funcPackagePath(function); pkg != "" {
+			buf.WriteString(pkg)
+			buf.WriteByte('/')
+			buf.WriteString(file[strings.LastIndexByte(file, '/')+1:])
+		} else {
+			buf.WriteString(shortFile(file))
+		}
+	default:
+		buf.WriteString(shortFile(file))
+	}
+}
+
+// shortFile returns the last directory and the file name of the path.
+func shortFile(file string) string {
+	i := strings.LastIndexByte(file, '/')
 	if i < 0 {
-		buf.WriteString(f.File)
-		return
+		return file
 	}
-	i = strings.LastIndexByte(f.File[:i], '/')
+	i = strings.LastIndexByte(file[:i], '/')
 	if i < 0 {
-		buf.WriteString(f.File)
-		return
+		return file
 	}
-	buf.WriteString(f.File[i+1:])
+	return file[i+1:]
 }
 
-func formatSourceValue(buf *buffer.Buffer, s *slog.Source) {
-	defer func() {
-		buf.WriteByte(':')
-		*buf = strconv.AppendInt(*buf, int64(s.Line), 10)
-	}()
+// funcPackagePath returns the package path of the full function name,
+// e.g. "github.com/icefed/zlog" of "github.com/icefed/zlog.(*JSONHandler).Handle".
+// The dots in the last element of the path are escaped as "%2e" in the function
+// names of the runtime, e.g. "gopkg.in/yaml%2ev3.(*Decoder).Decode", major version
+// suffixes are also recognized without escaping, e.g. "gopkg.in/yaml.v3.Unmarshal".
+func funcPackagePath(function string) string {
+	slash := strings.LastIndexByte(function, '/')
+	name := function[slash+1:]
+	dot := strings.IndexByte(name, '.')
+	if dot < 0 {
+		return ""
+	}
+	for {
+		next := strings.IndexByte(name[dot+1:], '.')
+		if next < 0 || !isMajorVersion(name[dot+1:dot+1+next]) {
+			break
+		}
+		dot += 1 + next
+	}
+	return strings.ReplaceAll(function[:slash+1+dot], "%2e", ".")
+}
 
-	i := strings.LastIndexByte(s.File, '/')
-	if i < 0 {
-		buf.WriteString(s.File)
-		return
+// isMajorVersion reports whether s is a major version suffix like "v3".
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
 	}
-	i = strings.LastIndexByte(s.File[:i], '/')
-	if i < 0 {
-		buf.WriteString(s.File)
-		return
+	for i := 1; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
 	}
-	buf.WriteString(s.File[i+1:])
+	return true
+}
+
+// shortFunction returns the function name without the package path,
+// e.g. "zlog.(*JSONHandler).Handle".
+func shortFunction(function string) string {
+	return function[strings.LastIndexByte(function, '/')+1:]
+}
+
+// funcNameFromPC returns the full function name of the pc.
+func funcNameFromPC(pc uintptr) string {
+	fs := runtime.CallersFrames([]uintptr{pc})
+	f, _ := fs.Next()
+	return f.Function
 }
@@ -42,7 +42,7 @@ func TestFormatSourceValue(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			formatSourceValue(buf, &test.source)
+			formatSourceValue(buf, CallerShort, &test.source)
 			if string(buf.Bytes()) != test.want {
 				t.Errorf("got %v, want %v", string(buf.Bytes()), test.want)
 			}
@@ -50,3 +50,58 @@ func TestFormatSourceValue(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatSourceCallerFormat(t *testing.T) {
+	source := &slog.Source{
+		Function: "github.com/icefed/zlog.(*JSONHandler).Handle",
+		File:     "/home/user/go/src/zlog/handler.go",
+		Line:     42,
+	}
+	tests := []struct {
+		format CallerFormat
+		source *slog.Source
+		want   string
+	}{
+		{CallerShort, source, "zlog/handler.go:42"},
+		{CallerFull, source, "/home/user/go/src/zlog/handler.go:42"},
+		{CallerPackage, source, "github.com/icefed/zlog/handler.go:42"},
+		{CallerPackage, &slog.Source{Function: "main.main", File: "/app/main.go", Line: 1}, "main/main.go:1"},
+		{CallerPackage, &slog.Source{File: "/app/main.go", Line: 1}, "app/main.go:1"},
+		{CallerPackage, &slog.Source{Function: "gopkg.in/yaml%2ev3.(*Decoder).Decode", File: "/go/pkg/mod/gopkg.in/yaml.v3@v3.0.1/decode.go", Line: 7}, "gopkg.in/yaml.v3/decode.go:7"},
+		{CallerShortFunc, source, "zlog/handler.go:42 zlog.(*JSONHandler).Handle"},
+	}
+
+	buf := buffer.New()
+	defer buf.Free()
+
+	for _, test := range tests {
+		formatSourceValue(buf, test.format, test.source)
+		if string(buf.Bytes()) != test.want {
+			t.Errorf("got %v, want %v", string(buf.Bytes()), test.want)
+		}
+		buf.Reset()
+	}
+}
+
+func TestFuncPackagePath(t *testing.T) {
+	tests := []struct {
+		function string
+		want     string
+	}{
+		{"github.com/icefed/zlog.(*JSONHandler).Handle", "github.com/icefed/zlog"},
+		{"github.com/icefed/zlog.New.func1", "github.com/icefed/zlog"},
+		{"main.main", "main"},
+		{"gopkg.in/yaml%2ev3.(*Decoder).Decode", "gopkg.in/yaml.v3"},
+		{"gopkg.in/yaml.v3.(*Decoder).Decode", "gopkg.in/yaml.v3"},
+		{"gopkg.in/yaml.v3.Unmarshal", "gopkg.in/yaml.v3"},
+		{"gopkg.in/go-playground/validator.v10.New", "gopkg.in/go-playground/validator.v10"},
+		{"github.com/nats-io/nats%2ego.Connect", "github.com/nats-io/nats.go"},
+		{"github.com/user/pkg.version", "github.com/user/pkg"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := funcPackagePath(test.function); got != test.want {
+			t.Errorf("funcPackagePath(%q) = %q, want %q", test.function, got, test.want)
+		}
+	}
+}
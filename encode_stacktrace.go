@@ -3,6 +3,7 @@ package zlog
 import (
 	"runtime"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/icefed/zlog/buffer"
@@ -12,6 +13,17 @@ import (
 // For ReplaceAttr, *stacktrace is the type of value in slog.Attr.
 type stacktrace struct {
 	pc uintptr
+	// c is the config of the handler to filter and format frames, nil means all frames are written.
+	c *Config
+}
+
+// stackFrame is a frame of the stack trace after filtered.
+type stackFrame struct {
+	function string
+	file     string
+	line     int
+	// repeated is the count of the collapsed consecutive same frames, 0 if not collapsed.
+	repeated int
 }
 
 type stacktracePCs []uintptr
@@ -31,19 +43,40 @@ func (st *stacktracePCs) Free() {
 	stacktracePCsPool.Put(st)
 }
 
-func formatStacktrace(buf *buffer.Buffer, sourcepc uintptr) {
-	sfs := runtime.CallersFrames([]uintptr{sourcepc})
+// frames returns the frames of the stack trace from the source pc, filtered by the
+// stacktrace options of the config. The source frame is always kept.
+func (st *stacktrace) frames() []stackFrame {
+	sfs := runtime.CallersFrames([]uintptr{st.pc})
 	sf, _ := sfs.Next()
 
-	writeCaller := func(fun, file string, line int, more bool) {
-		buf.WriteString(fun)
-		buf.Write([]byte("\n\t"))
-		buf.WriteString(file)
-		buf.WriteByte(':')
-		*buf = strconv.AppendInt(*buf, int64(line), 10)
-		if more {
-			buf.WriteByte('\n')
+	c := st.c
+	if c == nil {
+		c = &Config{}
+	}
+	var frames []stackFrame
+	add := func(f runtime.Frame, source bool) bool {
+		if !source && hasPackagePrefix(f.Function, c.StacktraceSkipPrefixes) {
+			return true
 		}
+		file := f.File
+		for _, prefix := range c.StacktraceTrimPaths {
+			if strings.HasPrefix(file, prefix) {
+				file = strings.TrimPrefix(file[len(prefix):], "/")
+				break
+			}
+		}
+		if c.StacktraceCollapse && len(frames) > 0 {
+			last := &frames[len(frames)-1]
+			if last.function == f.Function && last.file == file && last.line == f.Line {
+				last.repeated = max(last.repeated, 1) + 1
+				return true
+			}
+		}
+		if c.StacktraceMaxDepth > 0 && len(frames) >= c.StacktraceMaxDepth {
+			return false
+		}
+		frames = append(frames, stackFrame{function: f.Function, file: file, line: f.Line})
+		return true
 	}
 
 	pcs := *newStacktracePCs()
@@ -57,17 +90,56 @@ func formatStacktrace(buf *buffer.Buffer, sourcepc uintptr) {
 	for more {
 		f, more = fs.Next()
 		if found {
-			writeCaller(f.Function, f.File, f.Line, more)
+			if !add(f, false) {
+				break
+			}
 			continue
 		}
 		if f.Function == sf.Function && f.File == sf.File && f.Line == sf.Line {
-			writeCaller(f.Function, f.File, f.Line, more)
+			add(f, true)
 			found = true
 		}
 	}
 
 	if !found {
-		// write source
-		writeCaller(sf.Function, sf.File, sf.Line, more)
+		add(sf, true)
+	}
+	return frames
+}
+
+// hasPackagePrefix reports whether the function belongs to any of the packages
+// or has any of the prefixes, e.g. "net/http" matches "net/http.(*conn).serve"
+// but not "net/httputil.(*ReverseProxy).ServeHTTP".
+func hasPackagePrefix(function string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if !strings.HasPrefix(function, prefix) {
+			continue
+		}
+		if len(function) == len(prefix) || strings.HasSuffix(prefix, ".") || strings.HasSuffix(prefix, "/") {
+			return true
+		}
+		if c := function[len(prefix)]; c == '.' || c == '/' {
+			return true
+		}
+	}
+	return false
+}
+
+// formatStacktrace writes the frames as "function\n\tfile:line" separated by '\n'.
+func formatStacktrace(buf *buffer.Buffer, st *stacktrace) {
+	for i, f := range st.frames() {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(f.function)
+		buf.Write([]byte("\n\t"))
+		buf.WriteString(f.file)
+		buf.WriteByte(':')
+		*buf = strconv.AppendInt(*buf, int64(f.line), 10)
+		if f.repeated > 0 {
+			buf.WriteString(" (repeated ")
+			*buf = strconv.AppendInt(*buf, int64(f.repeated), 10)
+			buf.WriteString(" times)")
+		}
 	}
 }
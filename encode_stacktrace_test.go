@@ -30,7 +30,7 @@ var (
 func stacktraceCaller2(buf *buffer.Buffer) {
 	pcs := make([]uintptr, 1)
 	runtime.Callers(2, pcs)
-	formatStacktrace(buf, pcs[0])
+	formatStacktrace(buf, &stacktrace{pc: pcs[0]})
 }
 
 func stacktraceCaller1(buf *buffer.Buffer) {
@@ -51,9 +51,90 @@ func TestFormatStacktrace(t *testing.T) {
 	// not found
 	pcs := make([]uintptr, 1)
 	runtime.Callers(1, pcs)
-	formatStacktrace(buf, pcs[0])
+	formatStacktrace(buf, &stacktrace{pc: pcs[0]})
 	lines = bytes.Split(buf.Bytes(), []byte{'\n'})
 	if len(lines) != 2 {
 		t.Errorf("got %v, want %v", len(lines), 2)
 	}
 }
+
+func stacktraceRecursive(n int, f func()) {
+	if n == 0 {
+		f()
+		return
+	}
+	stacktraceRecursive(n-1, f)
+}
+
+func TestStacktraceFrames(t *testing.T) {
+	var frames []stackFrame
+	getFrames := func(c *Config) {
+		stacktraceRecursive(3, func() {
+			pcs := make([]uintptr, 1)
+			runtime.Callers(2, pcs)
+			frames = (&stacktrace{pc: pcs[0], c: c}).frames()
+		})
+	}
+
+	getFrames(nil)
+	all := len(frames)
+	if frames[0].function != "github.com/icefed/zlog.stacktraceRecursive" || frames[len(frames)-1].function != "runtime.goexit" {
+		t.Fatalf("unexpected frames %v", frames)
+	}
+
+	getFrames(&Config{StacktraceMaxDepth: 2})
+	if len(frames) != 2 {
+		t.Errorf("got %d frames, want 2", len(frames))
+	}
+
+	getFrames(&Config{StacktraceSkipPrefixes: []string{"runtime", "testing."}})
+	// testing.tRunner and runtime.goexit are skipped
+	if len(frames) != all-2 {
+		t.Errorf("got %d frames, want %d", len(frames), all-2)
+	}
+
+	getFrames(&Config{StacktraceCollapse: true})
+	// 3 recursive calls are collapsed into 1 frame
+	if len(frames) != all-2 || frames[1].repeated != 3 {
+		t.Errorf("got %d frames, repeated %d, want %d frames, repeated 3", len(frames), frames[1].repeated, all-2)
+	}
+	buf := buffer.New()
+	defer buf.Free()
+	stacktraceRecursive(2, func() {
+		pcs := make([]uintptr, 1)
+		runtime.Callers(2, pcs)
+		formatStacktrace(buf, &stacktrace{pc: pcs[0], c: &Config{StacktraceCollapse: true, StacktraceMaxDepth: 2}})
+	})
+	lines := bytes.Split(buf.Bytes(), []byte{'\n'})
+	if len(lines) != 4 || !bytes.HasSuffix(lines[3], []byte(" (repeated 2 times)")) {
+		t.Errorf("unexpected stack trace %s", buf.Bytes())
+	}
+
+	_, file, _, _ := runtime.Caller(0)
+	dir := file[:bytes.LastIndexByte([]byte(file), '/')]
+	getFrames(&Config{StacktraceTrimPaths: []string{"/not/exist", dir}})
+	if frames[0].file != "encode_stacktrace_test.go" {
+		t.Errorf("got file %s, want encode_stacktrace_test.go", frames[0].file)
+	}
+}
+
+func TestHasPackagePrefix(t *testing.T) {
+	tests := []struct {
+		function string
+		prefixes []string
+		want     bool
+	}{
+		{"net/http.(*conn).serve", []string{"net/http"}, true},
+		{"net/http/httputil.(*ReverseProxy).ServeHTTP", []string{"net/http"}, true},
+		{"net/httputil.Dump", []string{"net/http"}, false},
+		{"runtime.goexit", []string{"runtime"}, true},
+		{"main.runtimeCheck", []string{"runtime"}, false},
+		{"github.com/icefed/zlog.(*Logger).Info", []string{"github.com/icefed/zlog.(*Logger)."}, true},
+		{"main.main", nil, false},
+	}
+	for _, test := range tests {
+		if got := hasPackagePrefix(test.function, test.prefixes); got != test.want {
+			t.Errorf("hasPackagePrefix(%q, %q) = %v, want %v", test.function, test.prefixes, got, test.want)
+		}
+	}
+}
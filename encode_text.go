@@ -13,22 +13,35 @@ import (
 type textEncoder struct {
 	buf *buffer.Buffer
 
-	coloredLevel  bool
-	timeFormatter func([]byte, time.Time) []byte
-	replaceAttr   func(groups []string, a slog.Attr) slog.Attr
+	coloredLevel   bool
+	levelNames     LevelNames
+	lowercaseLevel bool
+	keyMapping     KeyMapping
+	callerFormat   CallerFormat
+	timeFormatter  func([]byte, time.Time) []byte
+	replaceAttr    func(groups []string, a slog.Attr) slog.Attr
 }
 
 func newTextEncoder(h *JSONHandler, buf *buffer.Buffer) *textEncoder {
 	return &textEncoder{
-		buf:           buf,
-		coloredLevel:  h.needColoredLevel(),
-		timeFormatter: h.c.TimeFormatter,
-		replaceAttr:   h.c.ReplaceAttr,
+		buf:            buf,
+		coloredLevel:   h.needColoredLevel(),
+		levelNames:     h.c.LevelNames,
+		lowercaseLevel: h.c.ValueMapping.LowercaseLevel,
+		keyMapping:     h.c.KeyMapping,
+		callerFormat:   h.c.CallerFormat,
+		timeFormatter:  h.c.TimeFormatter,
+		replaceAttr:    h.c.ReplaceAttr,
 	}
 }
 
 func (enc *textEncoder) Append(key string, v any) {
-	if enc.replaceAttr != nil {
+	// keys are not written, only the dropped attributes are ignored
+	key, ok, mapped := enc.keyMapping.mapKey(key)
+	if !ok {
+		return
+	}
+	if enc.replaceAttr != nil && !mapped {
 		var a slog.Attr
 		switch v := v.(type) {
 		// source PC
@@ -47,7 +60,7 @@ func (enc *textEncoder) Append(key string, v any) {
 	switch v := v.(type) {
 	// source PC
 	case uintptr:
-		formatSourceValueFromPC(enc.buf, v)
+		formatSourceValueFromPC(enc.buf, enc.callerFormat, v)
 	default:
 		enc.addValue(slog.AnyValue(v))
 	}
@@ -63,18 +76,18 @@ func (enc *textEncoder) addValue(v slog.Value) {
 	case slog.KindAny:
 		if l, ok := v.Any().(slog.Level); ok {
 			if enc.coloredLevel {
-				formatColorLevelValue(enc.buf, l)
+				formatColorLevelValue(enc.buf, l, enc.levelNames, enc.lowercaseLevel)
 			} else {
-				enc.buf.WriteString(l.String())
+				*enc.buf = appendLevelName(*enc.buf, enc.levelNames, l, enc.lowercaseLevel)
 			}
 			return
 		}
 		if s, ok := v.Any().(*slog.Source); ok && s != nil {
-			formatSourceValue(enc.buf, s)
+			formatSourceValue(enc.buf, enc.callerFormat, s)
 			return
 		}
 		if st, ok := v.Any().(*stacktrace); ok && st != nil {
-			formatStacktrace(enc.buf, st.pc)
+			formatStacktrace(enc.buf, st)
 			return
 		}
 		if tm, ok := v.Any().(encoding.TextMarshaler); ok {
@@ -71,7 +71,7 @@ func TestTextEncoder(t *testing.T) {
 		}, {
 			name:  "stacktrace",
 			key:   "stacktrace",
-			value: &stacktrace{getPC()},
+			value: &stacktrace{pc: getPC()},
 			want:  wantPCFunction + "\n\t" + wantPCFile + ":" + strconv.Itoa(wantPCLine),
 		}, {
 			name:  "ip",
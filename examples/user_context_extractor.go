@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/icefed/zlog"
+	"github.com/icefed/zlog/zhttp"
 )
 
 func AuthMiddleware(next http.Handler) http.Handler {
@@ -23,21 +24,6 @@ func AuthMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-func LogMiddleware(log *zlog.Logger, next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-
-		next.ServeHTTP(w, r)
-
-		duration := time.Since(start)
-		log.InfoContext(r.Context(), "Received request",
-			slog.String("method", r.Method),
-			slog.String("path", r.URL.Path),
-			slog.String("duration", duration.String()),
-		)
-	})
-}
-
 func hello(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Hello, World!"))
 }
@@ -70,10 +56,10 @@ func ExampleContextExtractorUserContext() {
 	log := zlog.New(h)
 
 	httpHandler := http.HandlerFunc(hello)
+	// set log middleware, inside the auth middleware to log the user of the request context
+	handler := zhttp.Middleware(&zhttp.Config{Logger: log})(httpHandler)
 	// set auth middleware
-	handler := AuthMiddleware(httpHandler)
-	// set log middleware
-	handler = LogMiddleware(log, handler)
+	handler = AuthMiddleware(handler)
 
 	log.Info("starting server, listening on port 38080")
 
@@ -113,5 +99,5 @@ func ExampleContextExtractorUserContext() {
 
 	// Output like:
 	// {"time":"2023-09-09T19:51:55.683+08:00","level":"INFO","msg":"starting server, listening on port 8080"}
-	// {"time":"2023-09-09T19:52:04.228+08:00","level":"INFO","msg":"Received request","user":{"name":"test@test.com","id":"a2067a0a-6b0b-4ee5-a049-16bdb8ed6ff5"},"method":"GET","path":"/api/v1/products","duration":"6.221µs"}
+	// {"time":"2023-09-09T19:52:04.228+08:00","level":"INFO","msg":"http request","request_id":"6b1e4f0ad3e2a9d4c5b7e8f901234567","user":{"name":"test@test.com","id":"a2067a0a-6b0b-4ee5-a049-16bdb8ed6ff5"},"method":"GET","path":"/api/v1/products","status":200,"bytes":13,"latency":"6.221µs","client_ip":"127.0.0.1","user_agent":"Go-http-client/1.1"}
 }
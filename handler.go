@@ -2,10 +2,14 @@ package zlog
 
 import (
 	"context"
+	"errors"
 	"io"
 	"log/slog"
+	"maps"
 	"os"
 	"slices"
+	"sync"
+	"syscall"
 	"time"
 
 	"golang.org/x/term"
@@ -22,11 +26,30 @@ type JSONHandler struct {
 	c *Config
 	// the writer is a terminal file descriptor.
 	isTerm bool
+	// mu serializes writes to the writer, shared by all derived handlers.
+	mu *sync.Mutex
+	// writeErrs counts and reports write errors, shared by all derived handlers.
+	writeErrs *writeErrors
+	// text encodes records as key=value pairs, used by TextHandler.
+	text bool
+
+	// name is the logger name set by WithName.
+	name string
+	// nameLevel is the level of the logger name in Config.NamedLevels, nil if not matched.
+	nameLevel slog.Leveler
 
 	groups                 []string
 	preformattedGroupAttrs []byte
 }
 
+var (
+	_ slog.Handler   = (*JSONHandler)(nil)
+	_ PCCapturer     = (*JSONHandler)(nil)
+	_ OptionsApplier = (*JSONHandler)(nil)
+	_ NamedHandler   = (*JSONHandler)(nil)
+	_ Syncer         = (*JSONHandler)(nil)
+)
+
 // ContextExtractor get attributes from context, that can be used in slog.Handler.
 type ContextExtractor func(context.Context) []slog.Attr
 
@@ -43,6 +66,21 @@ type Config struct {
 	// Writer is the writer to use. If nil, os.Stderr is used.
 	Writer io.Writer
 
+	// ConcurrentSafeWriter reports that Writer is safe for concurrent use.
+	// By default, Handle serializes writes with a lock shared by the handler and all
+	// handlers derived from it, set it true to skip the lock.
+	ConcurrentSafeWriter bool
+
+	// OnWriteError is called when writing a record to Writer fails, suppressed is
+	// the number of errors not reported since the last call because of WriteErrorInterval.
+	OnWriteError func(err error, suppressed uint64)
+	// ErrorOutput is the writer to report write errors, like OnWriteError.
+	// If nil, write errors are not written anywhere.
+	ErrorOutput io.Writer
+	// WriteErrorInterval is the minimum interval between reports of write errors,
+	// default is 1 second, negative means every error is reported.
+	WriteErrorInterval time.Duration
+
 	// TimeFormatter is the time formatter to use for buildin attribute time value. If nil, use format RFC3339Milli as default.
 	TimeFormatter AppendTimeFunc
 
@@ -55,6 +93,36 @@ type Config struct {
 	LevelKey   string
 	MessageKey string
 	SourceKey  string
+	// LoggerKey is the key for the logger name field, default is "logger".
+	LoggerKey string
+	// FunctionKey is the key for the caller function name field, the field is added
+	// only if it is set, e.g. "func", so the function is logged without AddSource.
+	FunctionKey string
+	// CallerFormat is the format of the source value, default is CallerShort.
+	CallerFormat CallerFormat
+	// SourceObject writes the source as an object with the keys in JSONHandler,
+	// instead of a string in CallerFormat, if it is not nil.
+	SourceObject *SourceObject
+
+	// KeyMapping renames or drops the built-in attributes by key, it is a faster
+	// alternative of ReplaceAttr for the simple cases, e.g. {"time": "ts", "source": ""}.
+	KeyMapping KeyMapping
+	// ValueMapping maps the values of the built-in attributes, e.g. lowercase level names.
+	ValueMapping ValueMapping
+
+	// NamedLevels sets the levels for the named loggers by name prefix,
+	// loggers not matching any name use Level.
+	NamedLevels NamedLevels
+	// LevelNames sets the names of levels in the output, e.g. LevelTrace as "TRACE".
+	LevelNames LevelNames
+
+	// Redactor masks the sensitive values of attributes, after ReplaceAttr is called.
+	Redactor *Redactor
+
+	// RichErrors encodes errors as objects with the message, the type, the wrapped
+	// errors chain and the stack trace of errors in JSONHandler, instead of only
+	// the message, e.g. {"msg":"...","type":"*fs.PathError","chain":[...]}.
+	RichErrors bool
 
 	// StacktraceEnabled enables stack trace for slog.Record.
 	StacktraceEnabled bool
@@ -63,6 +131,20 @@ type Config struct {
 	StacktraceLevel slog.Leveler
 	// StacktraceKey is the key for stacktrace field, default is "stacktrace".
 	StacktraceKey string
+	// StacktraceMaxDepth limits the number of frames in the stack trace, 0 means no limit.
+	StacktraceMaxDepth int
+	// StacktraceSkipPrefixes skips the frames of the packages or function name prefixes,
+	// e.g. "runtime", "net/http". The frame of the source is never skipped.
+	StacktraceSkipPrefixes []string
+	// StacktraceTrimPaths trims the prefixes from the file paths of frames, e.g. the
+	// GOROOT or the module root directory, the first matched prefix is trimmed.
+	StacktraceTrimPaths []string
+	// StacktraceCollapse collapses the consecutive same frames like deep recursions
+	// into one frame, with the count of repeated times.
+	StacktraceCollapse bool
+	// StacktraceFrames writes the stack trace as an array of {"func","file","line"}
+	// objects in JSONHandler, instead of a newline-joined string.
+	StacktraceFrames bool
 
 	// ContextExtractors will be used in Handler.Handle
 	ContextExtractors []ContextExtractor
@@ -74,6 +156,12 @@ type Config struct {
 func (c *Config) copy() *Config {
 	newConfig := *c
 	newConfig.ContextExtractors = slices.Clone(c.ContextExtractors)
+	newConfig.NamedLevels = maps.Clone(c.NamedLevels)
+	newConfig.LevelNames = maps.Clone(c.LevelNames)
+	newConfig.StacktraceSkipPrefixes = slices.Clone(c.StacktraceSkipPrefixes)
+	newConfig.StacktraceTrimPaths = slices.Clone(c.StacktraceTrimPaths)
+	newConfig.SourceObject = c.SourceObject.clone()
+	newConfig.KeyMapping = maps.Clone(c.KeyMapping)
 	return &newConfig
 }
 
@@ -98,6 +186,7 @@ var defaultConfig = Config{
 	LevelKey:          slog.LevelKey,
 	MessageKey:        slog.MessageKey,
 	SourceKey:         slog.SourceKey,
+	LoggerKey:         "logger",
 	StacktraceEnabled: false,
 	StacktraceLevel:   slog.LevelError,
 	StacktraceKey:     "stacktrace",
@@ -135,12 +224,23 @@ func NewJSONHandler(config *Config) *JSONHandler {
 		if c.SourceKey == "" {
 			c.SourceKey = defaultConfig.SourceKey
 		}
+		if c.LoggerKey == "" {
+			c.LoggerKey = defaultConfig.LoggerKey
+		}
 		c.ContextExtractors = slices.Clone(c.ContextExtractors)
+		c.NamedLevels = maps.Clone(c.NamedLevels)
+		c.LevelNames = maps.Clone(c.LevelNames)
+		c.StacktraceSkipPrefixes = slices.Clone(c.StacktraceSkipPrefixes)
+		c.StacktraceTrimPaths = slices.Clone(c.StacktraceTrimPaths)
+		c.SourceObject = c.SourceObject.clone()
+		c.KeyMapping = maps.Clone(c.KeyMapping)
 	}
 
 	handler := &JSONHandler{
-		c:      &c,
-		isTerm: isTerminal(c.Writer),
+		c:         &c,
+		isTerm:    isTerminal(c.Writer),
+		mu:        &sync.Mutex{},
+		writeErrs: &writeErrors{},
 	}
 	return handler
 }
@@ -148,17 +248,20 @@ func NewJSONHandler(config *Config) *JSONHandler {
 // Enabled reports whether the handler handles records at the given level. The handler ignores records whose level is lower.
 // https://pkg.go.dev/log/slog#Handler
 func (h *JSONHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if h.nameLevel != nil {
+		return level >= h.nameLevel.Level()
+	}
 	if h.c.Level == nil {
 		return level >= defaultConfig.Level.Level()
 	}
 	return level >= h.c.Level.Level()
 }
 
-// CapturePC returns true if the handler has AddSource option enabled or the stacktrace
-// is enabled at the given level.
+// CapturePC returns true if the handler has AddSource option enabled, FunctionKey set,
+// or the stacktrace is enabled at the given level.
 // Logger should set PC in the slog.Record if this function returns true.
 func (h *JSONHandler) CapturePC(level slog.Level) bool {
-	return h.c.AddSource || h.stacktraceEnabled(level)
+	return h.c.AddSource || h.c.FunctionKey != "" || h.stacktraceEnabled(level)
 }
 
 // WithOptions return a new handler with the given options.
@@ -169,9 +272,29 @@ func (h *JSONHandler) WithOptions(opts ...Option) *JSONHandler {
 		opts[i].apply(newHandler.c)
 	}
 	newHandler.isTerm = isTerminal(newHandler.c.Writer)
+	newHandler.nameLevel = newHandler.c.NamedLevels.lookup(newHandler.name)
 	return newHandler
 }
 
+// WithName implements the NamedHandler interface, returns a new handler with the
+// given logger name, the level of the name in Config.NamedLevels is used if matched.
+func (h *JSONHandler) WithName(name string) slog.Handler {
+	newHandler := h.clone()
+	newHandler.name = name
+	newHandler.nameLevel = newHandler.c.NamedLevels.lookup(name)
+	return newHandler
+}
+
+// ApplyOptions implements the OptionsApplier interface, same as WithOptions.
+func (h *JSONHandler) ApplyOptions(opts ...Option) slog.Handler {
+	return h.WithOptions(opts...)
+}
+
+// stacktraceConfig implements the stacktraceConfiger interface.
+func (h *JSONHandler) stacktraceConfig() *Config {
+	return h.c
+}
+
 // stacktraceEnabled reports whether the handler should record the stack trace of a slog.Record at the given level.
 func (h *JSONHandler) stacktraceEnabled(level slog.Level) bool {
 	if !h.c.StacktraceEnabled {
@@ -186,17 +309,94 @@ func (h *JSONHandler) Handle(ctx context.Context, r slog.Record) error {
 	buf := buffer.New()
 	defer buf.Free()
 
-	if h.c.Development {
+	switch {
+	case h.text:
+		h.encodeText(ctx, r, buf)
+	case h.c.Development:
 		h.encodeDevelopment(ctx, r, buf)
-	} else {
+	default:
 		h.encode(ctx, r, buf)
 	}
 
-	_, err := h.c.Writer.Write(buf.Bytes())
+	return h.write(buf.Bytes())
+}
+
+// write writes a whole encoded record to the writer, serializes concurrent
+// writes unless the writer is concurrent safe.
+func (h *JSONHandler) write(data []byte) error {
+	if !h.c.ConcurrentSafeWriter {
+		h.mu.Lock()
+	}
+	n, err := h.c.Writer.Write(data)
+	if !h.c.ConcurrentSafeWriter {
+		h.mu.Unlock()
+	}
+	if err == nil && n < len(data) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		// reported without the lock, OnWriteError may log by the same handler
+		h.writeErrs.add(h.c, err)
+	}
 	return err
 }
 
+// WriteErrors returns the number of records failed to write, including the records
+// written by handlers derived from the handler.
+func (h *JSONHandler) WriteErrors() uint64 {
+	return h.writeErrs.count.Load()
+}
+
+// Sync implements the Syncer interface, it flushes the writer if it implements
+// Flush() error like bufio.Writer, or Flush(context.Context) error like AsyncWriter,
+// then syncs the writer if it implements Sync() error like os.File. Like zap, os.Stdout
+// and os.Stderr are not synced, and errors of syncing files that do not support it,
+// e.g. pipes and terminals, are ignored.
+//
+// Flush(context.Context) is called without the lock of the handler, the writer must
+// be concurrent safe like AsyncWriter, so logging is not blocked by a stalled flush.
+func (h *JSONHandler) Sync() error {
+	var errs []error
+	switch w := h.c.Writer.(type) {
+	case interface{ Flush() error }:
+		errs = append(errs, h.locked(w.Flush))
+	case interface{ Flush(context.Context) error }:
+		errs = append(errs, w.Flush(context.Background()))
+	}
+	if w, ok := h.c.Writer.(interface{ Sync() error }); ok && !isStdStream(h.c.Writer) {
+		if err := h.locked(w.Sync); !isUnsupportedSyncError(err) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// locked calls f with the lock of the handler unless the writer is concurrent safe.
+func (h *JSONHandler) locked(f func() error) error {
+	if !h.c.ConcurrentSafeWriter {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+	}
+	return f()
+}
+
+// isStdStream reports whether w is os.Stdout or os.Stderr.
+func isStdStream(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && (f == os.Stdout || f == os.Stderr)
+}
+
+// isUnsupportedSyncError reports whether err is returned by syncing a file that
+// does not support it, e.g. EINVAL of pipes and ENOTTY of terminals.
+func isUnsupportedSyncError(err error) bool {
+	return errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTSUP) || errors.Is(err, syscall.ENOTTY)
+}
+
 func (h *JSONHandler) contextAttrs(ctx context.Context, f func(slog.Attr)) {
+	// attributes added by NewContext
+	for _, a := range ContextAttrs(ctx) {
+		f(a)
+	}
 	for _, ex := range h.c.ContextExtractors {
 		if ex == nil {
 			continue
@@ -214,24 +414,43 @@ const (
 
 func (h *JSONHandler) encodeDevelopment(ctx context.Context, r slog.Record, buf *buffer.Buffer) {
 	tenc := newTextEncoder(h, buf)
+	// sep is written before the next built-in field, the fields dropped by KeyMapping
+	// are skipped with their separators.
+	sep := ""
+	appendField := func(key string, v any) {
+		if _, ok, _ := h.c.KeyMapping.mapKey(key); !ok {
+			return
+		}
+		buf.WriteString(sep)
+		tenc.Append(key, v)
+		sep = "\t"
+	}
 	// time
 	// If r.Time is the zero time, ignore the time.
 	if !r.Time.IsZero() {
-		tenc.Append(h.c.TimeKey, r.Time)
-		buf.WriteString("  ")
+		appendField(h.c.TimeKey, r.Time)
+		if sep != "" {
+			sep = "  "
+		}
 	}
 	// level
-	tenc.Append(h.c.LevelKey, r.Level)
+	appendField(h.c.LevelKey, r.Level)
+	// logger name
+	if h.name != "" {
+		appendField(h.c.LoggerKey, h.name)
+	}
 	// source
 	// If r.PC is zero, ignore it.
 	if h.c.AddSource && r.PC != 0 {
-		buf.WriteByte('\t')
-		tenc.Append(h.c.SourceKey, r.PC)
+		appendField(h.c.SourceKey, r.PC)
+	}
+	// function
+	if h.c.FunctionKey != "" && r.PC != 0 {
+		appendField(h.c.FunctionKey, shortFunction(funcNameFromPC(r.PC)))
 	}
 	// message
 	if r.Message != "" {
-		buf.WriteByte('\t')
-		tenc.Append(h.c.MessageKey, r.Message)
+		appendField(h.c.MessageKey, r.Message)
 	}
 
 	size := len(buf.Bytes())
@@ -262,13 +481,56 @@ func (h *JSONHandler) encodeDevelopment(ctx context.Context, r slog.Record, buf
 	}
 	// stack trace
 	if h.stacktraceEnabled(r.Level) && r.PC != 0 {
-		tenc.Append(h.c.StacktraceKey, &stacktrace{r.PC})
+		tenc.Append(h.c.StacktraceKey, &stacktrace{pc: r.PC, c: h.c})
 	}
 	if *buf.LastByte() != lineEnding {
 		buf.WriteByte(lineEnding)
 	}
 }
 
+func (h *JSONHandler) encodeText(ctx context.Context, r slog.Record, buf *buffer.Buffer) {
+	enc := newLogfmtEncoder(h, buf)
+	// time
+	// If r.Time is the zero time, ignore the time.
+	if !r.Time.IsZero() {
+		enc.AppendTime(h.c.TimeKey, r.Time)
+	}
+	// level
+	enc.AppendLevel(h.c.LevelKey, r.Level)
+	// logger name
+	if h.name != "" {
+		enc.AppendLoggerName(h.c.LoggerKey, h.name)
+	}
+	// source
+	// If r.PC is zero, ignore it.
+	if h.c.AddSource && r.PC != 0 {
+		enc.AppendSourceFromPC(h.c.SourceKey, r.PC)
+	}
+	// function
+	if h.c.FunctionKey != "" && r.PC != 0 {
+		enc.AppendFunction(h.c.FunctionKey, r.PC)
+	}
+	// message
+	enc.AppendMessage(h.c.MessageKey, r.Message)
+
+	// preformatted attrs
+	enc.AppendFormatted(h.preformattedGroupAttrs)
+	// add context attrs
+	h.contextAttrs(ctx, func(attr slog.Attr) {
+		enc.AppendAttr(attr)
+	})
+	// add record attrs
+	r.Attrs(func(attr slog.Attr) bool {
+		enc.AppendAttr(attr)
+		return true
+	})
+	// stack trace
+	if h.stacktraceEnabled(r.Level) && r.PC != 0 {
+		enc.AppendStacktrace(h.c.StacktraceKey, &stacktrace{pc: r.PC, c: h.c})
+	}
+	buf.WriteByte(lineEnding)
+}
+
 func (h *JSONHandler) encode(ctx context.Context, r slog.Record, buf *buffer.Buffer) {
 	enc := newJSONEncoder(h, buf)
 	buf.WriteByte('{')
@@ -279,11 +541,19 @@ func (h *JSONHandler) encode(ctx context.Context, r slog.Record, buf *buffer.Buf
 	}
 	// level
 	enc.AppendLevel(h.c.LevelKey, r.Level)
+	// logger name
+	if h.name != "" {
+		enc.AppendLoggerName(h.c.LoggerKey, h.name)
+	}
 	// source
 	// If r.PC is zero, ignore it.
 	if h.c.AddSource && r.PC != 0 {
 		enc.AppendSourceFromPC(h.c.SourceKey, r.PC)
 	}
+	// function
+	if h.c.FunctionKey != "" && r.PC != 0 {
+		enc.AppendFunction(h.c.FunctionKey, r.PC)
+	}
 	// message
 	enc.AppendMessage(h.c.MessageKey, r.Message)
 
@@ -301,7 +571,7 @@ func (h *JSONHandler) encode(ctx context.Context, r slog.Record, buf *buffer.Buf
 	enc.CloseGroups()
 	// stack trace
 	if h.stacktraceEnabled(r.Level) && r.PC != 0 {
-		enc.AppendStacktrace(h.c.StacktraceKey, &stacktrace{r.PC})
+		enc.AppendStacktrace(h.c.StacktraceKey, &stacktrace{pc: r.PC, c: h.c})
 	}
 	buf.WriteByte('}')
 	buf.WriteByte(lineEnding)
@@ -319,6 +589,13 @@ func (h *JSONHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 }
 
 func (h *JSONHandler) addAttrs(attrs []slog.Attr) {
+	if h.text {
+		enc := newLogfmtEncoder(h, (*buffer.Buffer)(&h.preformattedGroupAttrs))
+		for i := range attrs {
+			enc.AppendAttr(attrs[i])
+		}
+		return
+	}
 	enc := newJSONEncoder(h, (*buffer.Buffer)(&h.preformattedGroupAttrs))
 	for i := range attrs {
 		enc.AppendAttr(attrs[i])
@@ -337,6 +614,11 @@ func (h *JSONHandler) WithGroup(name string) slog.Handler {
 }
 
 func (h *JSONHandler) addGroup(name string) {
+	if h.text {
+		// keys are qualified by h.groups, nothing to preformat.
+		h.groups = append(h.groups, name)
+		return
+	}
 	enc := newJSONEncoder(h, (*buffer.Buffer)(&h.preformattedGroupAttrs))
 	enc.OpenGroup(name)
 	h.groups = append(h.groups, name)
@@ -346,6 +628,11 @@ func (h *JSONHandler) clone() *JSONHandler {
 	newHandler := &JSONHandler{
 		c:                      h.c.copy(),
 		isTerm:                 h.isTerm,
+		mu:                     h.mu,
+		writeErrs:              h.writeErrs,
+		text:                   h.text,
+		name:                   h.name,
+		nameLevel:              h.nameLevel,
 		groups:                 slices.Clip(h.groups),
 		preformattedGroupAttrs: slices.Clip(h.preformattedGroupAttrs),
 	}
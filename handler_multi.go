@@ -0,0 +1,148 @@
+package zlog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+)
+
+var (
+	_ slog.Handler   = (*MultiHandler)(nil)
+	_ PCCapturer     = (*MultiHandler)(nil)
+	_ OptionsApplier = (*MultiHandler)(nil)
+	_ NamedHandler   = (*MultiHandler)(nil)
+	_ Syncer         = (*MultiHandler)(nil)
+)
+
+// MultiHandler implements the slog.Handler interface, it dispatches records
+// to multiple handlers, like a tee.
+//
+// Each handler keeps its own level, a record is only passed to the handlers
+// that are enabled at the record's level.
+type MultiHandler struct {
+	handlers []slog.Handler
+}
+
+// NewMultiHandler creates a slog handler that dispatches records to all the given handlers.
+// Nil handlers are ignored.
+func NewMultiHandler(handlers ...slog.Handler) *MultiHandler {
+	h := &MultiHandler{
+		handlers: make([]slog.Handler, 0, len(handlers)),
+	}
+	for _, handler := range handlers {
+		if isNil(handler) {
+			continue
+		}
+		h.handlers = append(h.handlers, handler)
+	}
+	return h
+}
+
+// Enabled reports whether any of the handlers handles records at the given level.
+// https://pkg.go.dev/log/slog#Handler
+func (h *MultiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// CapturePC returns true if any of the handlers needs the pc at the given level,
+// handlers that do not implement PCCapturer always need it.
+func (h *MultiHandler) CapturePC(level slog.Level) bool {
+	for _, handler := range h.handlers {
+		c, ok := handler.(PCCapturer)
+		if !ok || c.CapturePC(level) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyOptions implements the OptionsApplier interface, options are applied
+// to the handlers that implement OptionsApplier.
+func (h *MultiHandler) ApplyOptions(opts ...Option) slog.Handler {
+	return h.apply(func(handler slog.Handler) slog.Handler {
+		if oa, ok := handler.(OptionsApplier); ok {
+			return oa.ApplyOptions(opts...)
+		}
+		return handler
+	})
+}
+
+// WithName implements the NamedHandler interface, the name is passed to
+// the handlers that implement NamedHandler.
+func (h *MultiHandler) WithName(name string) slog.Handler {
+	return h.apply(func(handler slog.Handler) slog.Handler {
+		if nh, ok := handler.(NamedHandler); ok {
+			return nh.WithName(name)
+		}
+		return handler
+	})
+}
+
+// Sync implements the Syncer interface, it syncs the handlers that implement Syncer,
+// and returns the joined errors.
+func (h *MultiHandler) Sync() error {
+	var errs []error
+	for _, handler := range h.handlers {
+		if s, ok := handler.(Syncer); ok {
+			if err := s.Sync(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Handle passes a clone of the record to each handler enabled at the record's level,
+// and returns the joined errors of the handlers.
+// https://pkg.go.dev/log/slog#Handler
+func (h *MultiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h.handlers {
+		if !handler.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := handler.Handle(ctx, r.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithAttrs implements the slog.Handler WithAttrs method.
+// https://pkg.go.dev/log/slog#Handler
+func (h *MultiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.apply(func(handler slog.Handler) slog.Handler {
+		return handler.WithAttrs(slices.Clone(attrs))
+	})
+}
+
+// WithGroup implements the slog.Handler WithGroup method.
+// https://pkg.go.dev/log/slog#Handler
+func (h *MultiHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.apply(func(handler slog.Handler) slog.Handler {
+		return handler.WithGroup(name)
+	})
+}
+
+// apply returns a new MultiHandler with handlers transformed by f.
+func (h *MultiHandler) apply(f func(slog.Handler) slog.Handler) *MultiHandler {
+	newHandler := &MultiHandler{
+		handlers: make([]slog.Handler, len(h.handlers)),
+	}
+	for i, handler := range h.handlers {
+		newHandler.handlers[i] = f(handler)
+	}
+	return newHandler
+}
@@ -0,0 +1,130 @@
+package zlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/slogtest"
+)
+
+type errorWriter struct {
+	err error
+}
+
+func (w *errorWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+func TestMultiHandlerSlogtest(t *testing.T) {
+	var buf1, buf2 bytes.Buffer
+	h := NewMultiHandler(
+		NewJSONHandler(&Config{Writer: &buf1, IgnoreEmptyGroup: true}),
+		nil,
+		slog.NewJSONHandler(&buf2, nil),
+	)
+
+	results := func(buf *bytes.Buffer) func() []map[string]any {
+		return func() []map[string]any {
+			var ms []map[string]any
+			for _, line := range bytes.Split(buf.Bytes(), []byte{'\n'}) {
+				if len(line) == 0 {
+					continue
+				}
+				var m map[string]any
+				if err := json.Unmarshal(line, &m); err != nil {
+					t.Fatal(err)
+				}
+				ms = append(ms, m)
+			}
+			return ms
+		}
+	}
+	if err := slogtest.TestHandler(h, results(&buf1)); err != nil {
+		t.Fatal(err)
+	}
+	buf1.Reset()
+	buf2.Reset()
+	if err := slogtest.TestHandler(h, results(&buf2)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMultiHandler(t *testing.T) {
+	var all, errs, text bytes.Buffer
+	replaceAttr := WithReplaceAttr(func(_ []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey {
+			return slog.Attr{}
+		}
+		return a
+	})
+	h := NewMultiHandler(
+		NewJSONHandler(&Config{
+			HandlerOptions: slog.HandlerOptions{Level: slog.LevelDebug},
+			Writer:         &all,
+		}).WithOptions(replaceAttr),
+		NewJSONHandler(&Config{
+			HandlerOptions: slog.HandlerOptions{Level: slog.LevelError},
+			Writer:         &errs,
+		}).WithOptions(replaceAttr),
+		NewTextHandler(&Config{
+			HandlerOptions: slog.HandlerOptions{Level: slog.LevelInfo},
+			Writer:         &text,
+		}).WithOptions(replaceAttr),
+	)
+
+	if !h.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("want debug level enabled")
+	}
+	if h.CapturePC(slog.LevelError) {
+		t.Error("want CapturePC false")
+	}
+	if !h.ApplyOptions(WithAddSource(true)).(*MultiHandler).CapturePC(slog.LevelError) {
+		t.Error("want CapturePC true after WithAddSource")
+	}
+	if !NewMultiHandler(slog.NewJSONHandler(&all, nil)).CapturePC(slog.LevelInfo) {
+		t.Error("want CapturePC true for handler not implementing PCCapturer")
+	}
+
+	log := New(h).WithGroup("g").With("app", "test")
+	log.Debug("debug")
+	log.Error("error", "key", "value")
+
+	want := `{"level":"DEBUG","msg":"debug","g":{"app":"test"}}
+{"level":"ERROR","msg":"error","g":{"app":"test","key":"value"}}
+`
+	if all.String() != want {
+		t.Errorf("got %s, want %s", all.String(), want)
+	}
+	want = `{"level":"ERROR","msg":"error","g":{"app":"test","key":"value"}}
+`
+	if errs.String() != want {
+		t.Errorf("got %s, want %s", errs.String(), want)
+	}
+	want = `level=ERROR msg=error g.app=test g.key=value
+`
+	if text.String() != want {
+		t.Errorf("got %s, want %s", text.String(), want)
+	}
+}
+
+func TestMultiHandlerErrors(t *testing.T) {
+	err1 := errors.New("write error 1")
+	err2 := errors.New("write error 2")
+	var buf bytes.Buffer
+	h := NewMultiHandler(
+		NewJSONHandler(&Config{Writer: &errorWriter{err1}}),
+		NewJSONHandler(&Config{Writer: &buf}),
+		NewJSONHandler(&Config{Writer: &errorWriter{err2}}),
+	)
+	err := h.Handle(context.Background(), slog.NewRecord(testTime, slog.LevelInfo, "test", 0))
+	if !errors.Is(err, err1) || !errors.Is(err, err2) {
+		t.Errorf("got %v, want joined errors", err)
+	}
+	if !strings.Contains(buf.String(), `"msg":"test"`) {
+		t.Errorf("got %s, want record written", buf.String())
+	}
+}
@@ -0,0 +1,216 @@
+package zlog
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	_ slog.Handler   = (*SamplingHandler)(nil)
+	_ PCCapturer     = (*SamplingHandler)(nil)
+	_ OptionsApplier = (*SamplingHandler)(nil)
+	_ NamedHandler   = (*SamplingHandler)(nil)
+	_ Syncer         = (*SamplingHandler)(nil)
+)
+
+// SamplingDecision is the decision of the SamplingHandler for a record.
+type SamplingDecision uint32
+
+const (
+	// LogDropped means the record is dropped by the sampler.
+	LogDropped SamplingDecision = 1 << iota
+	// LogSampled means the record is passed to the wrapped handler.
+	LogSampled
+)
+
+// SamplingConfig the configuration for the SamplingHandler.
+type SamplingConfig struct {
+	// Tick is the interval of sampling, default is 1 second.
+	Tick time.Duration
+	// First is the number of records with the same level and message logged in each tick.
+	First int
+	// Thereafter means every Thereafter-th record is logged after First records in each tick.
+	// If zero, all the records after First are dropped.
+	Thereafter int
+
+	// Hook is called with the sampling decision of every record, it can be used to
+	// count the dropped records.
+	Hook func(ctx context.Context, r slog.Record, decision SamplingDecision)
+}
+
+// SamplingHandler implements the slog.Handler interface, it wraps a handler and
+// samples records to limit the logging overhead of repeated messages, like the
+// zap sampler.
+//
+// Records are counted by their level and message, in each tick the first
+// First records are logged, and then every Thereafter-th record. Dropped records
+// are not passed to the wrapped handler, so they are never encoded.
+type SamplingHandler struct {
+	h slog.Handler
+	c SamplingConfig
+
+	counts *samplingCounters
+}
+
+const defaultSamplingTick = time.Second
+
+// NewSamplingHandler creates a slog handler that samples the records passed to h.
+// If config is nil, a default configuration that logs the first record each second is used.
+func NewSamplingHandler(h slog.Handler, config *SamplingConfig) *SamplingHandler {
+	var c SamplingConfig
+	if config != nil {
+		c = *config
+	} else {
+		c.First = 1
+	}
+	if c.Tick <= 0 {
+		c.Tick = defaultSamplingTick
+	}
+	return &SamplingHandler{
+		h:      h,
+		c:      c,
+		counts: &samplingCounters{},
+	}
+}
+
+// Enabled reports whether the wrapped handler handles records at the given level.
+// https://pkg.go.dev/log/slog#Handler
+func (h *SamplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+// CapturePC calls CapturePC of the wrapped handler, returns true if it does
+// not implement PCCapturer.
+func (h *SamplingHandler) CapturePC(level slog.Level) bool {
+	if c, ok := h.h.(PCCapturer); ok {
+		return c.CapturePC(level)
+	}
+	return true
+}
+
+// stacktraceConfig implements the stacktraceConfiger interface, it returns the
+// config of the wrapped handler, nil if it is not a zlog handler.
+func (h *SamplingHandler) stacktraceConfig() *Config {
+	if c, ok := h.h.(stacktraceConfiger); ok {
+		return c.stacktraceConfig()
+	}
+	return nil
+}
+
+// ApplyOptions implements the OptionsApplier interface, options are applied
+// to the wrapped handler if it implements OptionsApplier.
+func (h *SamplingHandler) ApplyOptions(opts ...Option) slog.Handler {
+	oa, ok := h.h.(OptionsApplier)
+	if !ok {
+		return h
+	}
+	return h.wrap(oa.ApplyOptions(opts...))
+}
+
+// WithName implements the NamedHandler interface, the name is passed to
+// the wrapped handler if it implements NamedHandler.
+func (h *SamplingHandler) WithName(name string) slog.Handler {
+	nh, ok := h.h.(NamedHandler)
+	if !ok {
+		return h
+	}
+	return h.wrap(nh.WithName(name))
+}
+
+// Sync implements the Syncer interface, it syncs the wrapped handler if it implements Syncer.
+func (h *SamplingHandler) Sync() error {
+	if s, ok := h.h.(Syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// Handle passes the record to the wrapped handler if it is sampled.
+// https://pkg.go.dev/log/slog#Handler
+func (h *SamplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	n := h.counts.get(r.Level, r.Message).incCheckReset(t, h.c.Tick)
+	if n > uint64(h.c.First) && (h.c.Thereafter <= 0 || (n-uint64(h.c.First))%uint64(h.c.Thereafter) != 0) {
+		if h.c.Hook != nil {
+			h.c.Hook(ctx, r, LogDropped)
+		}
+		return nil
+	}
+	if h.c.Hook != nil {
+		h.c.Hook(ctx, r, LogSampled)
+	}
+	return h.h.Handle(ctx, r)
+}
+
+// WithAttrs implements the slog.Handler WithAttrs method.
+// The new handler shares the sampling counters.
+// https://pkg.go.dev/log/slog#Handler
+func (h *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.wrap(h.h.WithAttrs(attrs))
+}
+
+// WithGroup implements the slog.Handler WithGroup method.
+// The new handler shares the sampling counters.
+// https://pkg.go.dev/log/slog#Handler
+func (h *SamplingHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.wrap(h.h.WithGroup(name))
+}
+
+// wrap returns a new SamplingHandler wrapping handler with the same counters.
+func (h *SamplingHandler) wrap(handler slog.Handler) *SamplingHandler {
+	return &SamplingHandler{
+		h:      handler,
+		c:      h.c,
+		counts: h.counts,
+	}
+}
+
+const samplingCountersSize = 4096
+
+// samplingCounters counts the records by the hash of level and message.
+type samplingCounters [samplingCountersSize]samplingCounter
+
+func (cs *samplingCounters) get(level slog.Level, msg string) *samplingCounter {
+	// fnv32a
+	hash := uint32(2166136261)
+	for i := 0; i < len(msg); i++ {
+		hash ^= uint32(msg[i])
+		hash *= 16777619
+	}
+	hash ^= uint32(level)
+	hash *= 16777619
+	return &cs[hash%samplingCountersSize]
+}
+
+type samplingCounter struct {
+	resetAt atomic.Int64
+	counter atomic.Uint64
+}
+
+// incCheckReset increases the counter, and resets it if the tick is over.
+func (c *samplingCounter) incCheckReset(t time.Time, tick time.Duration) uint64 {
+	tn := t.UnixNano()
+	resetAfter := c.resetAt.Load()
+	if resetAfter > tn {
+		return c.counter.Add(1)
+	}
+
+	c.counter.Store(1)
+	newResetAfter := tn + tick.Nanoseconds()
+	if !c.resetAt.CompareAndSwap(resetAfter, newResetAfter) {
+		// another goroutine has reset the counter
+		return c.counter.Add(1)
+	}
+	return 1
+}
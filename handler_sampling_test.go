@@ -0,0 +1,97 @@
+package zlog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSamplingHandler(t *testing.T) {
+	var buf bytes.Buffer
+	var sampled, dropped int
+	h := NewSamplingHandler(NewJSONHandler(&Config{
+		HandlerOptions: slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		},
+		Writer: &buf,
+	}), &SamplingConfig{
+		Tick:       time.Minute,
+		First:      2,
+		Thereafter: 3,
+		Hook: func(_ context.Context, _ slog.Record, decision SamplingDecision) {
+			switch decision {
+			case LogSampled:
+				sampled++
+			case LogDropped:
+				dropped++
+			}
+		},
+	})
+	// derived handlers share the counters
+	log := New(h.WithAttrs([]slog.Attr{slog.String("app", "test")}))
+
+	for i := 0; i < 10; i++ {
+		log.Info("sampled message")
+		New(h).WithGroup("g").Info("sampled message")
+	}
+	log.Error("sampled message")
+	log.Info("another message")
+
+	// records 1, 2, 5, 8, 11, 14, 17, 20 of the message at info level are logged
+	if got := strings.Count(buf.String(), `"msg":"sampled message"`); got != 8+1 {
+		t.Errorf("got %v records, want %v", got, 8+1)
+	}
+	if got := strings.Count(buf.String(), `"msg":"another message"`); got != 1 {
+		t.Errorf("got %v records, want %v", got, 1)
+	}
+	if sampled != 10 || dropped != 12 {
+		t.Errorf("got sampled %v dropped %v, want sampled %v dropped %v", sampled, dropped, 10, 12)
+	}
+}
+
+func TestSamplingHandlerTick(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSamplingHandler(NewJSONHandler(&Config{Writer: &buf}), &SamplingConfig{
+		Tick:  time.Second,
+		First: 1,
+	})
+	now := time.Now()
+	for _, d := range []time.Duration{0, 100 * time.Millisecond, 999 * time.Millisecond, time.Second, 1500 * time.Millisecond, 2 * time.Second} {
+		h.Handle(context.Background(), slog.NewRecord(now.Add(d), slog.LevelInfo, "test", 0))
+	}
+	if got := strings.Count(buf.String(), "\n"); got != 3 {
+		t.Errorf("got %v records, want %v", got, 3)
+	}
+}
+
+func TestSamplingHandlerDelegate(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSamplingHandler(NewJSONHandler(&Config{Writer: &buf}), nil)
+	if h.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("want debug level disabled")
+	}
+	if h.CapturePC(slog.LevelInfo) {
+		t.Error("want CapturePC false")
+	}
+	h2 := h.ApplyOptions(WithAddSource(true)).(*SamplingHandler)
+	if !h2.CapturePC(slog.LevelInfo) {
+		t.Error("want CapturePC true after WithAddSource")
+	}
+	if h2.counts != h.counts {
+		t.Error("want counters shared")
+	}
+	if h.WithGroup("") != slog.Handler(h) || h.WithAttrs(nil) != slog.Handler(h) {
+		t.Error("want the same handler")
+	}
+
+	h3 := NewSamplingHandler(slog.NewJSONHandler(&buf, nil), nil)
+	if !h3.CapturePC(slog.LevelInfo) {
+		t.Error("want CapturePC true for handler not implementing PCCapturer")
+	}
+	if h3.ApplyOptions(WithAddSource(true)) != slog.Handler(h3) {
+		t.Error("want the same handler for handler not implementing OptionsApplier")
+	}
+}
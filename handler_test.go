@@ -1,13 +1,20 @@
 package zlog
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
+	"os"
 	"reflect"
 	"regexp"
+	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"testing/slogtest"
 	"time"
@@ -180,6 +187,161 @@ func TestHandlerColoredLevel(t *testing.T) {
 	log.Error("test")
 }
 
+func TestHandlerLevelNames(t *testing.T) {
+	var buf bytes.Buffer
+	names := LevelNames{
+		LevelTrace:    "TRACE",
+		LevelCritical: "CRITICAL",
+	}
+	removeTime := func(_ []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey {
+			return slog.Attr{}
+		}
+		return a
+	}
+	h := NewJSONHandler(&Config{
+		HandlerOptions: slog.HandlerOptions{
+			Level: LevelTrace,
+		},
+		Writer:     &buf,
+		LevelNames: names,
+	})
+	tests := []struct {
+		name     string
+		h        slog.Handler
+		expected string
+	}{
+		{
+			name:     "json",
+			h:        h,
+			expected: `{"level":"TRACE","msg":"test","level":"CRITICAL"}`,
+		}, {
+			name:     "json with ReplaceAttr",
+			h:        h.WithOptions(WithReplaceAttr(removeTime)),
+			expected: `{"level":"TRACE","msg":"test","level":"CRITICAL"}`,
+		}, {
+			name:     "development",
+			h:        h.WithOptions(WithDevelopment(true), WithReplaceAttr(removeTime)),
+			expected: "TRACE\ttest\t{\"level\":\"CRITICAL\"}",
+		}, {
+			name:     "text",
+			h:        NewTextHandler(h.c).WithOptions(WithReplaceAttr(removeTime)),
+			expected: `level=TRACE msg=test level=CRITICAL`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf.Reset()
+			log := slog.New(test.h)
+			log.Log(context.Background(), LevelTrace, "test", "level", LevelCritical)
+			got := strings.TrimSpace(buf.String())
+			if test.name == "json" {
+				// remove time
+				got = got[:1] + got[strings.Index(got, `"level"`):]
+			}
+			if got != test.expected {
+				t.Errorf("got %q, want %q", got, test.expected)
+			}
+		})
+	}
+}
+
+var lineRegexp = regexp.MustCompile(`\.go:\d+`)
+
+func TestHandlerCaller(t *testing.T) {
+	var buf bytes.Buffer
+	removeTime := func(_ []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey {
+			return slog.Attr{}
+		}
+		return a
+	}
+	h := NewJSONHandler(&Config{
+		Writer:      &buf,
+		FunctionKey: "func",
+	}).WithOptions(WithReplaceAttr(removeTime))
+	function := "github.com/icefed/zlog.TestHandlerCaller.func2"
+	_, file, _, _ := runtime.Caller(0)
+	file = shortFile(file)
+	tests := []struct {
+		name     string
+		h        slog.Handler
+		expected string
+	}{
+		{
+			name:     "json",
+			h:        h,
+			expected: `{"level":"INFO","func":"` + function + `","msg":"test"}`,
+		}, {
+			name:     "json with source",
+			h:        h.WithOptions(WithAddSource(true), WithCallerFormat(CallerPackage)),
+			expected: `{"level":"INFO","source":"github.com/icefed/zlog/handler_test.go:N","func":"` + function + `","msg":"test"}`,
+		}, {
+			name:     "text",
+			h:        NewTextHandler(h.c).WithOptions(WithAddSource(true), WithCallerFormat(CallerShortFunc)),
+			expected: `level=INFO source="` + file + `:N zlog.TestHandlerCaller.func2" func=` + function + ` msg=test`,
+		}, {
+			name:     "development",
+			h:        h.WithOptions(WithDevelopment(true), WithAddSource(true)),
+			expected: "INFO\t" + file + ":N\tzlog.TestHandlerCaller.func2\ttest",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf.Reset()
+			New(test.h).Info("test")
+			// replace the line number
+			got := lineRegexp.ReplaceAllString(strings.TrimSpace(buf.String()), ".go:N")
+			if got != test.expected {
+				t.Errorf("got %q, want %q", got, test.expected)
+			}
+		})
+	}
+
+	t.Run("capture pc", func(t *testing.T) {
+		if NewJSONHandler(nil).CapturePC(slog.LevelInfo) {
+			t.Error("unexpected CapturePC")
+		}
+		if !h.CapturePC(slog.LevelInfo) {
+			t.Error("want CapturePC with FunctionKey")
+		}
+	})
+}
+
+func TestHandlerStacktraceFrames(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewJSONHandler(&Config{
+		Writer:                 &buf,
+		StacktraceEnabled:      true,
+		StacktraceFrames:       true,
+		StacktraceMaxDepth:     2,
+		StacktraceSkipPrefixes: []string{"testing"},
+	})
+	for _, h := range []slog.Handler{h, h.WithOptions(WithReplaceAttr(func(_ []string, a slog.Attr) slog.Attr { return a }))} {
+		buf.Reset()
+		New(h).Error("test")
+		var m struct {
+			Stacktrace []struct {
+				Func string
+				File string
+				Line int
+			}
+		}
+		if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+			t.Fatal(err)
+		}
+		if len(m.Stacktrace) != 2 {
+			t.Fatalf("got %d frames, want 2: %s", len(m.Stacktrace), buf.String())
+		}
+		if f := m.Stacktrace[0]; f.Func != "github.com/icefed/zlog.TestHandlerStacktraceFrames" || f.Line == 0 {
+			t.Errorf("unexpected frame %+v", f)
+		}
+		if f := m.Stacktrace[1]; f.Func != "runtime.goexit" {
+			t.Errorf("unexpected frame %+v", f)
+		}
+	}
+}
+
 type userKey struct{}
 type user struct {
 	Name string
@@ -318,3 +480,204 @@ func testContextAttrs(t *testing.T, h *JSONHandler, f func() []map[string]any) {
 		})
 	}
 }
+
+// concurrencyWriter records whether Write has been called concurrently.
+type concurrencyWriter struct {
+	writing    atomic.Int32
+	concurrent atomic.Bool
+	lines      atomic.Int32
+}
+
+func (w *concurrencyWriter) Write(p []byte) (int, error) {
+	if w.writing.Add(1) > 1 {
+		w.concurrent.Store(true)
+	}
+	defer w.writing.Add(-1)
+	time.Sleep(time.Microsecond)
+	w.lines.Add(int32(bytes.Count(p, []byte{lineEnding})))
+	return len(p), nil
+}
+
+func TestHandlerConcurrentWrite(t *testing.T) {
+	tests := []struct {
+		name string
+		safe bool
+	}{
+		{
+			name: "serialized",
+			safe: false,
+		}, {
+			name: "concurrent safe writer",
+			safe: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := &concurrencyWriter{}
+			h := NewJSONHandler(&Config{
+				Writer:               w,
+				ConcurrentSafeWriter: test.safe,
+			})
+			handlers := []slog.Handler{
+				h,
+				h.WithAttrs([]slog.Attr{slog.String("key", "value")}),
+				h.WithGroup("g"),
+				h.WithOptions(WithAddSource(true)),
+			}
+
+			var wg sync.WaitGroup
+			for _, handler := range handlers {
+				log := slog.New(handler)
+				for i := 0; i < 8; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for j := 0; j < 50; j++ {
+							log.Info("test", "j", j)
+						}
+					}()
+				}
+			}
+			wg.Wait()
+
+			// concurrent writes are only allowed for concurrent safe writers
+			if !test.safe && w.concurrent.Load() {
+				t.Error("got concurrent write, want serialized writes")
+			}
+			if got := w.lines.Load(); got != int32(len(handlers)*8*50) {
+				t.Errorf("got %v lines, want %v", got, len(handlers)*8*50)
+			}
+		})
+	}
+}
+
+// syncWriter records the calls of Flush and Sync.
+type syncWriter struct {
+	bytes.Buffer
+	flushed, synced int
+	err             error
+}
+
+func (w *syncWriter) Flush() error {
+	w.flushed++
+	return nil
+}
+
+func (w *syncWriter) Sync() error {
+	w.synced++
+	return w.err
+}
+
+func TestHandlerSync(t *testing.T) {
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	h := NewJSONHandler(&Config{Writer: bw})
+	slog.New(h).Info("test")
+	if buf.Len() != 0 {
+		t.Fatal("want the record buffered")
+	}
+	if err := h.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"msg":"test"`) {
+		t.Errorf("got %q, want the record flushed", buf.String())
+	}
+
+	aw := NewAsyncWriter(&buf, nil)
+	defer aw.Close(context.Background())
+	buf.Reset()
+	h = NewJSONHandler(&Config{Writer: aw})
+	slog.New(h).Info("async")
+	if err := h.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"msg":"async"`) {
+		t.Errorf("got %q, want the record flushed", buf.String())
+	}
+
+	sw := &syncWriter{}
+	h = NewJSONHandler(&Config{Writer: sw})
+	if err := h.Sync(); err != nil || sw.flushed != 1 || sw.synced != 1 {
+		t.Errorf("got err %v flushed %d synced %d, want flushed and synced", err, sw.flushed, sw.synced)
+	}
+	sw.err = errors.New("sync failed")
+	if err := h.Sync(); !errors.Is(err, sw.err) {
+		t.Errorf("got err %v, want %v", err, sw.err)
+	}
+	sw.err = &os.PathError{Op: "sync", Path: "/dev/stderr", Err: syscall.EINVAL}
+	if err := h.Sync(); err != nil {
+		t.Errorf("got err %v, want EINVAL ignored", err)
+	}
+
+	sw.err = &os.PathError{Op: "sync", Path: "/dev/tty", Err: syscall.ENOTTY}
+	if err := h.Sync(); err != nil {
+		t.Errorf("got err %v, want ENOTTY ignored", err)
+	}
+
+	if err := NewJSONHandler(&Config{Writer: &buf}).Sync(); err != nil {
+		t.Errorf("got err %v, want nil for writer without Flush and Sync", err)
+	}
+	// stdout and stderr are not synced
+	if err := NewJSONHandler(&Config{Writer: os.Stderr}).Sync(); err != nil {
+		t.Errorf("got err %v, want nil for stderr", err)
+	}
+	// syncing a pipe fails with EINVAL
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if err := NewJSONHandler(&Config{Writer: w}).Sync(); err != nil {
+		t.Errorf("got err %v, want nil for pipe", err)
+	}
+}
+
+// stalledFlushWriter blocks Flush until the context is done or release is closed.
+type stalledFlushWriter struct {
+	bytes.Buffer
+	mu       sync.Mutex
+	flushing chan struct{}
+	release  chan struct{}
+}
+
+func (w *stalledFlushWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.Buffer.Write(p)
+}
+
+func (w *stalledFlushWriter) Flush(ctx context.Context) error {
+	close(w.flushing)
+	select {
+	case <-w.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestHandlerSyncStalledFlush(t *testing.T) {
+	w := &stalledFlushWriter{flushing: make(chan struct{}), release: make(chan struct{})}
+	h := NewJSONHandler(&Config{Writer: w})
+	synced := make(chan error)
+	go func() {
+		synced <- h.Sync()
+	}()
+	<-w.flushing
+
+	logged := make(chan struct{})
+	go func() {
+		defer close(logged)
+		slog.New(h).Info("test")
+	}()
+	select {
+	case <-logged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logging is blocked by a stalled flush")
+	}
+	close(w.release)
+	if err := <-synced; err != nil {
+		t.Error(err)
+	}
+}
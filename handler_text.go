@@ -0,0 +1,99 @@
+package zlog
+
+import (
+	"context"
+	"log/slog"
+)
+
+// TextHandler implements the slog.Handler interface, transforming r.Record
+// into logfmt, a sequence of key=value pairs on a single line.
+//
+// TextHandler shares the Config with JSONHandler, keys of attributes in groups
+// are qualified by the group names with dots, e.g. "request.method=GET".
+// Values are quoted if they contain spaces, '=', '"' or non-printable characters.
+// Development is ignored by TextHandler.
+type TextHandler struct {
+	h *JSONHandler
+}
+
+var (
+	_ slog.Handler   = (*TextHandler)(nil)
+	_ PCCapturer     = (*TextHandler)(nil)
+	_ OptionsApplier = (*TextHandler)(nil)
+	_ NamedHandler   = (*TextHandler)(nil)
+	_ Syncer         = (*TextHandler)(nil)
+)
+
+// NewTextHandler creates a slog handler that writes log messages as key=value pairs.
+// If config is nil, a default configuration is used.
+func NewTextHandler(config *Config) *TextHandler {
+	h := NewJSONHandler(config)
+	h.text = true
+	return &TextHandler{h: h}
+}
+
+// Enabled reports whether the handler handles records at the given level. The handler ignores records whose level is lower.
+// https://pkg.go.dev/log/slog#Handler
+func (h *TextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+// CapturePC returns true if the handler has AddSource option enabled, FunctionKey set,
+// or the stacktrace is enabled at the given level.
+// Logger should set PC in the slog.Record if this function returns true.
+func (h *TextHandler) CapturePC(level slog.Level) bool {
+	return h.h.CapturePC(level)
+}
+
+// stacktraceConfig implements the stacktraceConfiger interface.
+func (h *TextHandler) stacktraceConfig() *Config {
+	return h.h.c
+}
+
+// WithOptions return a new handler with the given options.
+// Options will override the hander's config.
+func (h *TextHandler) WithOptions(opts ...Option) *TextHandler {
+	return &TextHandler{h: h.h.WithOptions(opts...)}
+}
+
+// ApplyOptions implements the OptionsApplier interface, same as WithOptions.
+func (h *TextHandler) ApplyOptions(opts ...Option) slog.Handler {
+	return h.WithOptions(opts...)
+}
+
+// WithName implements the NamedHandler interface, returns a new handler with the
+// given logger name.
+func (h *TextHandler) WithName(name string) slog.Handler {
+	return &TextHandler{h: h.h.WithName(name).(*JSONHandler)}
+}
+
+// WriteErrors returns the number of records failed to write, see JSONHandler.WriteErrors.
+func (h *TextHandler) WriteErrors() uint64 {
+	return h.h.WriteErrors()
+}
+
+// Sync implements the Syncer interface, see JSONHandler.Sync.
+func (h *TextHandler) Sync() error {
+	return h.h.Sync()
+}
+
+// Handle formats its argument Record as key=value pairs on a single line.
+// https://pkg.go.dev/log/slog#Handler
+func (h *TextHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.h.Handle(ctx, r)
+}
+
+// WithAttrs implements the slog.Handler WithAttrs method.
+// https://pkg.go.dev/log/slog#Handler
+func (h *TextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &TextHandler{h: h.h.WithAttrs(attrs).(*JSONHandler)}
+}
+
+// WithGroup implements the slog.Handler WithGroup method.
+// https://pkg.go.dev/log/slog#Handler
+func (h *TextHandler) WithGroup(name string) slog.Handler {
+	return &TextHandler{h: h.h.WithGroup(name).(*JSONHandler)}
+}
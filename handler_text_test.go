@@ -0,0 +1,193 @@
+package zlog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/slogtest"
+	"time"
+)
+
+// parseLogfmt parses a logfmt line, keys qualified by groups are returned as nested maps.
+func parseLogfmt(t *testing.T, line string) map[string]any {
+	t.Helper()
+	m := make(map[string]any)
+	for len(line) > 0 {
+		line = strings.TrimLeft(line, " ")
+		i := strings.IndexByte(line, '=')
+		if i < 0 {
+			t.Fatalf("no '=' in %q", line)
+		}
+		key := line[:i]
+		line = line[i+1:]
+
+		var value any
+		if strings.HasPrefix(line, `"`) {
+			quoted, err := strconv.QuotedPrefix(line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			line = line[len(quoted):]
+			value, _ = strconv.Unquote(quoted)
+		} else {
+			j := strings.IndexByte(line, ' ')
+			if j < 0 {
+				j = len(line)
+			}
+			value = line[:j]
+			switch line[:j] {
+			case "true":
+				value = true
+			case "false":
+				value = false
+			}
+			line = line[j:]
+		}
+
+		keys := strings.Split(key, ".")
+		cur := m
+		for _, k := range keys[:len(keys)-1] {
+			sub, ok := cur[k].(map[string]any)
+			if !ok {
+				sub = make(map[string]any)
+				cur[k] = sub
+			}
+			cur = sub
+		}
+		cur[keys[len(keys)-1]] = value
+	}
+	return m
+}
+
+func TestTextHandlerSlogtest(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewTextHandler(&Config{
+		HandlerOptions: slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		},
+		Writer: &buf,
+	})
+	h = h.WithOptions(WithAddSource(true))
+
+	results := func() []map[string]any {
+		var ms []map[string]any
+		for _, line := range bytes.Split(buf.Bytes(), []byte{'\n'}) {
+			if len(line) == 0 {
+				continue
+			}
+			ms = append(ms, parseLogfmt(t, string(line)))
+		}
+		return ms
+	}
+	err := slogtest.TestHandler(h, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTextHandler(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	h := NewTextHandler(&Config{
+		HandlerOptions: slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		},
+		Writer: buf,
+	})
+	testTime := time.Date(2023, 8, 16, 1, 2, 3, 666000000, time.UTC)
+
+	tests := []struct {
+		name   string
+		h      slog.Handler
+		record func() slog.Record
+		want   string
+	}{
+		{
+			name: "quoted values",
+			h:    h,
+			record: func() slog.Record {
+				r := slog.NewRecord(testTime, slog.LevelInfo, "hello world", 0)
+				r.AddAttrs(slog.String("empty", ""), slog.String("eq", "a=b"), slog.String("quote", `"q"`),
+					slog.String("newline", "a\nb"), slog.String("plain", "value"))
+				return r
+			},
+			want: `time=2023-08-16T01:02:03.666Z level=INFO msg="hello world" empty="" eq="a=b" quote="\"q\"" newline="a\nb" plain=value`,
+		}, {
+			name: "groups",
+			h: h.WithAttrs([]slog.Attr{slog.Int("a", 1)}).WithGroup("g").
+				WithAttrs([]slog.Attr{slog.Bool("b", true)}).WithGroup("h"),
+			record: func() slog.Record {
+				r := slog.NewRecord(time.Time{}, slog.LevelWarn, "msg", 0)
+				r.AddAttrs(slog.Group("i", slog.Float64("f", 1.5), slog.Group("empty")), slog.Duration("d", time.Second))
+				return r
+			},
+			want: `level=WARN msg=msg a=1 g.b=true g.h.i.f=1.5 g.h.d=1s`,
+		}, {
+			name: "any values",
+			h: h.WithOptions(WithReplaceAttr(func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			})),
+			record: func() slog.Record {
+				r := slog.NewRecord(testTime, slog.LevelError, "", 0)
+				r.AddAttrs(slog.Any("err", context.Canceled), slog.Any("bytes", []byte("a b")),
+					slog.Any("nil", nil), slog.Any("map", map[string]int{"a": 1}))
+				return r
+			},
+			want: `level=ERROR msg="" err="context canceled" bytes="a b" nil=<nil> map=map[a:1]`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.h.Handle(context.Background(), test.record()); err != nil {
+				t.Fatal(err)
+			}
+			got := strings.TrimSuffix(buf.String(), "\n")
+			if got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+			buf.Reset()
+		})
+	}
+}
+
+func TestTextHandlerContextAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewTextHandler(&Config{
+		HandlerOptions: slog.HandlerOptions{
+			AddSource: true,
+			Level:     slog.LevelDebug,
+		},
+		StacktraceEnabled: true,
+		Writer:            &buf,
+	})
+	h = h.WithOptions(WithContextExtractor(userContextExtractor))
+
+	results := func() []map[string]any {
+		var ms []map[string]any
+		for _, line := range bytes.Split(buf.Bytes(), []byte{'\n'}) {
+			if len(line) == 0 {
+				continue
+			}
+			ms = append(ms, parseLogfmt(t, string(line)))
+		}
+		return ms
+	}
+	testContextAttrs(t, h.h, results)
+
+	buf.Reset()
+	log := New(h.h)
+	log.Error("test")
+	source := getCallerLineSource(-1)
+	m := parseLogfmt(t, strings.TrimSuffix(buf.String(), "\n"))
+	if !strings.HasPrefix(m["stacktrace"].(string), "github.com/icefed/zlog.TestTextHandlerContextAttrs") {
+		t.Errorf("got %v, want stacktrace", m["stacktrace"])
+	}
+	if m["source"] != source {
+		t.Errorf("got %v, want %v", m["source"], source)
+	}
+}
@@ -0,0 +1,101 @@
+// Package groupattrs collects the attributes of records as slog.Attr, for the
+// handlers that do not encode them, e.g. the handlers of zlogtest and zotel.
+package groupattrs
+
+import (
+	"log/slog"
+	"slices"
+)
+
+// Attrs holds the groups and attributes added by the WithGroup and WithAttrs
+// methods of a slog.Handler. The zero value holds nothing, Attrs is immutable
+// and safe to share.
+type Attrs struct {
+	goas []groupOrAttrs
+}
+
+// groupOrAttrs is a group or attributes added by WithGroup or WithAttrs.
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+// WithAttrs returns Attrs with the attributes added to the current group,
+// the attributes are resolved like Resolve.
+func (g Attrs) WithAttrs(attrs []slog.Attr) Attrs {
+	var resolved []slog.Attr
+	for _, a := range attrs {
+		resolved = appendResolved(resolved, a)
+	}
+	if len(resolved) == 0 {
+		return g
+	}
+	return g.with(groupOrAttrs{attrs: resolved})
+}
+
+// WithGroup returns Attrs with the group opened, the empty name is ignored.
+func (g Attrs) WithGroup(name string) Attrs {
+	if name == "" {
+		return g
+	}
+	return g.with(groupOrAttrs{group: name})
+}
+
+func (g Attrs) with(goa groupOrAttrs) Attrs {
+	return Attrs{goas: append(slices.Clip(g.goas), goa)}
+}
+
+// Resolve returns the attributes added by WithAttrs, the context attributes, e.g.
+// zlog.ContextAttrs, and the attributes of the record, the latter two are nested in
+// the groups added by WithGroup. Values are resolved, empty attributes and groups
+// are ignored, and the attributes of groups with empty keys are inlined, like the
+// slog.Handler rules.
+func (g Attrs) Resolve(ctxAttrs []slog.Attr, r slog.Record) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(ctxAttrs)+r.NumAttrs())
+	for _, a := range ctxAttrs {
+		attrs = appendResolved(attrs, a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = appendResolved(attrs, a)
+		return true
+	})
+	// nest the record attrs in the groups from the innermost one
+	for i := len(g.goas) - 1; i >= 0; i-- {
+		goa := g.goas[i]
+		if goa.group == "" {
+			attrs = append(slices.Clip(goa.attrs), attrs...)
+			continue
+		}
+		// groups without attributes are ignored
+		if len(attrs) == 0 {
+			continue
+		}
+		attrs = []slog.Attr{{Key: goa.group, Value: slog.GroupValue(attrs...)}}
+	}
+	return attrs
+}
+
+// appendResolved appends the attribute with its value and the values in groups
+// resolved, empty attributes and empty groups are ignored, and the attributes
+// of groups with empty keys are inlined.
+func appendResolved(attrs []slog.Attr, a slog.Attr) []slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return attrs
+	}
+	if a.Value.Kind() != slog.KindGroup {
+		return append(attrs, a)
+	}
+
+	var groupAttrs []slog.Attr
+	for _, ga := range a.Value.Group() {
+		groupAttrs = appendResolved(groupAttrs, ga)
+	}
+	if len(groupAttrs) == 0 {
+		return attrs
+	}
+	if a.Key == "" {
+		return append(attrs, groupAttrs...)
+	}
+	return append(attrs, slog.Attr{Key: a.Key, Value: slog.GroupValue(groupAttrs...)})
+}
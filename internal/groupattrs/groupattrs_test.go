@@ -0,0 +1,63 @@
+package groupattrs
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestAttrs(t *testing.T) {
+	var g Attrs
+	g = g.WithAttrs([]slog.Attr{slog.String("a", "1"), {}, slog.Group("empty")})
+	g = g.WithGroup("")
+	g = g.WithGroup("g1").WithAttrs([]slog.Attr{slog.Group("", slog.Int("b", 2))})
+	g2 := g.WithGroup("g2")
+	g3 := g.WithGroup("g3")
+
+	ctxAttrs := []slog.Attr{slog.String("request_id", "abc")}
+	r := slog.NewRecord(time.Time{}, slog.LevelInfo, "msg", 0)
+	r.AddAttrs(slog.Any("c", slog.StringValue("3")), slog.Group("d", slog.Bool("e", true)))
+
+	tests := []struct {
+		name     string
+		g        Attrs
+		ctxAttrs []slog.Attr
+		r        slog.Record
+		expected []slog.Attr
+	}{
+		{
+			name:     "zero",
+			ctxAttrs: ctxAttrs,
+			r:        r,
+			expected: []slog.Attr{slog.String("request_id", "abc"), slog.String("c", "3"), slog.Group("d", slog.Bool("e", true))},
+		}, {
+			name:     "groups",
+			g:        g2,
+			ctxAttrs: ctxAttrs,
+			r:        r,
+			expected: []slog.Attr{
+				slog.String("a", "1"),
+				slog.Group("g1",
+					slog.Int("b", 2),
+					slog.Group("g2", slog.String("request_id", "abc"), slog.String("c", "3"), slog.Group("d", slog.Bool("e", true))),
+				),
+			},
+		}, {
+			name: "empty group",
+			g:    g3,
+			r:    slog.NewRecord(time.Time{}, slog.LevelInfo, "msg", 0),
+			expected: []slog.Attr{
+				slog.String("a", "1"),
+				slog.Group("g1", slog.Int("b", 2)),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.g.Resolve(test.ctxAttrs, test.r)
+			if !slog.GroupValue(got...).Equal(slog.GroupValue(test.expected...)) {
+				t.Errorf("got %v, want %v", got, test.expected)
+			}
+		})
+	}
+}
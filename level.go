@@ -0,0 +1,311 @@
+package zlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// LevelTrace is the level for very verbose logs, lower than slog.LevelDebug.
+	LevelTrace slog.Level = -8
+	// LevelNotice is the level for normal but significant logs, between slog.LevelInfo and slog.LevelWarn.
+	LevelNotice slog.Level = 2
+	// LevelCritical is the level for critical errors, between slog.LevelError and LevelPanic.
+	LevelCritical slog.Level = 10
+	// LevelPanic is the level of Logger.Panic, records are logged before panic.
+	LevelPanic slog.Level = 12
+	// LevelFatal is the level of Logger.Fatal, records are logged before the process exits.
+	LevelFatal slog.Level = 16
+)
+
+// LevelNames maps levels to their names, used by Config.LevelNames to name
+// the levels in the output, e.g.
+//
+//	zlog.LevelNames{
+//		zlog.LevelTrace:    "TRACE",
+//		zlog.LevelNotice:   "NOTICE",
+//		zlog.LevelCritical: "CRITICAL",
+//	}
+//
+// Levels not in the map are named by LevelString, only the levels defined by zlog
+// have their own names, other levels are named like slog.Level.String, e.g. add
+// LevelTrace + 1: "TRACE+1" to the map to name it relative to LevelTrace.
+type LevelNames map[slog.Level]string
+
+// name returns the name of the level.
+func (n LevelNames) name(l slog.Level) string {
+	if name, ok := n[l]; ok {
+		return name
+	}
+	return LevelString(l)
+}
+
+// Parse parses the level from its name case-insensitively, the names in the map
+// are matched first, the highest level is returned if levels share the name, then
+// the names recognized by ParseLevel.
+func (n LevelNames) Parse(s string) (slog.Level, error) {
+	s = strings.TrimSpace(s)
+	var (
+		level slog.Level
+		found bool
+	)
+	for l, name := range n {
+		if strings.EqualFold(name, s) && (!found || l > level) {
+			level, found = l, true
+		}
+	}
+	if found {
+		return level, nil
+	}
+	return ParseLevel(s)
+}
+
+var zlogLevels = []struct {
+	name  string
+	level slog.Level
+}{
+	{"TRACE", LevelTrace},
+	{"NOTICE", LevelNotice},
+	{"CRITICAL", LevelCritical},
+	{"PANIC", LevelPanic},
+	{"FATAL", LevelFatal},
+}
+
+// ParseLevel parses the level from its name case-insensitively, like slog.Level.UnmarshalText,
+// it also recognizes the names of the levels defined by zlog, e.g. "trace", "FATAL", "NOTICE+1".
+func ParseLevel(s string) (slog.Level, error) {
+	s = strings.TrimSpace(s)
+	name, offset := s, 0
+	if i := strings.IndexAny(s, "+-"); i >= 0 {
+		name = s[:i]
+		var err error
+		if offset, err = strconv.Atoi(s[i:]); err != nil {
+			return 0, fmt.Errorf("zlog: level string %q: %w", s, err)
+		}
+	}
+	for _, zl := range zlogLevels {
+		if strings.EqualFold(name, zl.name) {
+			return zl.level + slog.Level(offset), nil
+		}
+	}
+
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, err
+	}
+	return l, nil
+}
+
+// LevelString returns the default name of the level, the levels defined by zlog are
+// named "TRACE", "NOTICE", "CRITICAL", "PANIC" and "FATAL", other levels are named
+// by slog.Level.String, e.g. "DEBUG-1", "INFO+2". Handlers outside zlog can call it
+// to name the levels like JSONHandler.
+func LevelString(l slog.Level) string {
+	switch l {
+	case LevelTrace:
+		return "TRACE"
+	case LevelNotice:
+		return "NOTICE"
+	case LevelCritical:
+		return "CRITICAL"
+	case LevelPanic:
+		return "PANIC"
+	case LevelFatal:
+		return "FATAL"
+	default:
+		return l.String()
+	}
+}
+
+var (
+	_ slog.Leveler = (*AtomicLevel)(nil)
+	_ http.Handler = (*AtomicLevel)(nil)
+)
+
+// AtomicLevel is a slog.Leveler that can be changed safely at runtime, like zap.AtomicLevel.
+// It can be shared by many handlers as Config.Level, and also serves as an
+// http.Handler to get and change the level.
+type AtomicLevel struct {
+	v     slog.LevelVar
+	names atomic.Pointer[LevelNames]
+}
+
+// NewAtomicLevel creates an AtomicLevel with the given level.
+func NewAtomicLevel(level slog.Level) *AtomicLevel {
+	l := &AtomicLevel{}
+	l.v.Set(level)
+	return l
+}
+
+// Level implements the slog.Leveler interface.
+func (l *AtomicLevel) Level() slog.Level {
+	return l.v.Level()
+}
+
+// SetLevel changes the level.
+func (l *AtomicLevel) SetLevel(level slog.Level) {
+	l.v.Set(level)
+}
+
+// SetLevelNames sets the level names used by String, MarshalText, UnmarshalText
+// and ServeHTTP, usually the same as Config.LevelNames.
+func (l *AtomicLevel) SetLevelNames(names LevelNames) {
+	names = maps.Clone(names)
+	l.names.Store(&names)
+}
+
+func (l *AtomicLevel) levelNames() LevelNames {
+	if names := l.names.Load(); names != nil {
+		return *names
+	}
+	return nil
+}
+
+// String returns the name of the level.
+func (l *AtomicLevel) String() string {
+	return l.levelNames().name(l.Level())
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (l *AtomicLevel) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, it sets the level by name.
+func (l *AtomicLevel) UnmarshalText(data []byte) error {
+	level, err := l.levelNames().Parse(string(data))
+	if err != nil {
+		return err
+	}
+	l.SetLevel(level)
+	return nil
+}
+
+const maxLevelRequestSize = 1 << 10
+
+type levelPayload struct {
+	Level string `json:"level"`
+}
+
+type errorPayload struct {
+	Error string `json:"error"`
+}
+
+// ServeHTTP implements the http.Handler interface.
+//
+// GET returns the current level as JSON, e.g. {"level":"INFO"}.
+//
+// PUT changes the level, the new level is read from a JSON body {"level":"DEBUG"},
+// or from the form value "level" in the body or the query, e.g.
+//
+//	curl -X PUT -d '{"level":"debug"}' http://localhost:8080/log/level
+//	curl -X PUT -d level=debug http://localhost:8080/log/level
+func (l *AtomicLevel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		level, err := decodeLevelRequest(r, l.levelNames())
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			enc.Encode(errorPayload{Error: err.Error()})
+			return
+		}
+		l.SetLevel(level)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		enc.Encode(errorPayload{Error: "only GET and PUT are supported"})
+		return
+	}
+	enc.Encode(levelPayload{Level: l.String()})
+}
+
+func decodeLevelRequest(r *http.Request, names LevelNames) (slog.Level, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxLevelRequestSize))
+	if err != nil {
+		return 0, err
+	}
+
+	var name string
+	if body = bytes.TrimSpace(body); len(body) > 0 && body[0] == '{' {
+		var payload levelPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return 0, fmt.Errorf("malformed request body: %v", err)
+		}
+		name = payload.Level
+	} else {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return 0, fmt.Errorf("malformed request body: %v", err)
+		}
+		name = values.Get("level")
+		if name == "" {
+			name = r.URL.Query().Get("level")
+		}
+	}
+	if name == "" {
+		return 0, fmt.Errorf("must specify a level")
+	}
+
+	return names.Parse(name)
+}
+
+// NamedLevels maps logger name prefixes to levels, used by Config.NamedLevels
+// to set the levels of named loggers.
+//
+// A prefix matches the logger name if it equals the name or it is followed by a "."
+// in the name, e.g. "db" matches "db" and "db.pool", but not "dbx". The longest
+// matched prefix is used.
+type NamedLevels map[string]slog.Leveler
+
+// ParseNamedLevels parses NamedLevels from a comma separated list of name=level
+// pairs, e.g. "db=debug,http=warn", levels are parsed by ParseLevel.
+func ParseNamedLevels(s string) (NamedLevels, error) {
+	levels := NamedLevels{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, levelName, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("zlog: invalid named level %q", pair)
+		}
+		level, err := ParseLevel(levelName)
+		if err != nil {
+			return nil, fmt.Errorf("zlog: invalid named level %q: %w", pair, err)
+		}
+		levels[name] = level
+	}
+	return levels, nil
+}
+
+// lookup returns the level of the longest prefix matching name, nil if not matched.
+func (nl NamedLevels) lookup(name string) slog.Leveler {
+	if name == "" || len(nl) == 0 {
+		return nil
+	}
+	for {
+		if level, ok := nl[name]; ok {
+			return level
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return nil
+		}
+		name = name[:i]
+	}
+}
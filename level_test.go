@@ -0,0 +1,279 @@
+package zlog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAtomicLevel(t *testing.T) {
+	level := NewAtomicLevel(slog.LevelInfo)
+	var buf bytes.Buffer
+	h := NewJSONHandler(&Config{
+		HandlerOptions: slog.HandlerOptions{
+			Level: level,
+		},
+		Writer: &buf,
+	})
+	h2 := h.WithGroup("g")
+
+	if h.Enabled(context.Background(), slog.LevelDebug) || h2.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("want debug level disabled")
+	}
+	level.SetLevel(slog.LevelDebug)
+	if !h.Enabled(context.Background(), slog.LevelDebug) || !h2.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("want debug level enabled")
+	}
+
+	if err := level.UnmarshalText([]byte("warn")); err != nil {
+		t.Fatal(err)
+	}
+	text, _ := level.MarshalText()
+	if string(text) != "WARN" || level.String() != "WARN" {
+		t.Errorf("got %s, want %s", text, "WARN")
+	}
+	if err := level.UnmarshalText([]byte("unknown")); err == nil {
+		t.Error("want error for unknown level")
+	}
+}
+
+func TestAtomicLevelServeHTTP(t *testing.T) {
+	level := NewAtomicLevel(slog.LevelInfo)
+	server := httptest.NewServer(level)
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		method      string
+		query       string
+		contentType string
+		body        string
+		wantCode    int
+		wantBody    string
+		wantLevel   slog.Level
+	}{
+		{
+			name:      "get",
+			method:    http.MethodGet,
+			wantCode:  http.StatusOK,
+			wantBody:  `{"level":"INFO"}`,
+			wantLevel: slog.LevelInfo,
+		}, {
+			name:        "put json",
+			method:      http.MethodPut,
+			contentType: "application/json",
+			body:        `{"level":"debug"}`,
+			wantCode:    http.StatusOK,
+			wantBody:    `{"level":"DEBUG"}`,
+			wantLevel:   slog.LevelDebug,
+		}, {
+			name:        "put form",
+			method:      http.MethodPut,
+			contentType: "application/x-www-form-urlencoded",
+			body:        "level=error",
+			wantCode:    http.StatusOK,
+			wantBody:    `{"level":"ERROR"}`,
+			wantLevel:   slog.LevelError,
+		}, {
+			name:      "put query",
+			method:    http.MethodPut,
+			query:     "?level=WARN%2B1",
+			wantCode:  http.StatusOK,
+			wantBody:  `{"level":"WARN+1"}`,
+			wantLevel: slog.LevelWarn + 1,
+		}, {
+			name:        "put invalid level",
+			method:      http.MethodPut,
+			contentType: "application/json",
+			body:        `{"level":"unknown"}`,
+			wantCode:    http.StatusBadRequest,
+			wantBody:    `{"error":"slog: level string \"unknown\": unknown name"}`,
+			wantLevel:   slog.LevelWarn + 1,
+		}, {
+			name:      "put empty",
+			method:    http.MethodPut,
+			wantCode:  http.StatusBadRequest,
+			wantBody:  `{"error":"must specify a level"}`,
+			wantLevel: slog.LevelWarn + 1,
+		}, {
+			name:      "post",
+			method:    http.MethodPost,
+			wantCode:  http.StatusMethodNotAllowed,
+			wantBody:  `{"error":"only GET and PUT are supported"}`,
+			wantLevel: slog.LevelWarn + 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(test.method, server.URL+test.query, strings.NewReader(test.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.contentType != "" {
+				req.Header.Set("Content-Type", test.contentType)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			var body bytes.Buffer
+			body.ReadFrom(res.Body)
+
+			if res.StatusCode != test.wantCode {
+				t.Errorf("got status %v, want %v", res.StatusCode, test.wantCode)
+			}
+			if got := strings.TrimSpace(body.String()); got != test.wantBody {
+				t.Errorf("got %s, want %s", got, test.wantBody)
+			}
+			if level.Level() != test.wantLevel {
+				t.Errorf("got level %v, want %v", level.Level(), test.wantLevel)
+			}
+		})
+	}
+}
+
+func TestNamedLevels(t *testing.T) {
+	levels, err := ParseNamedLevels("db=debug, http=warn,db.pool=error,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		level slog.Leveler
+	}{
+		{"", nil},
+		{"app", nil},
+		{"dbx", nil},
+		{"db", slog.LevelDebug},
+		{"db.conn", slog.LevelDebug},
+		{"db.pool", slog.LevelError},
+		{"db.pool.idle", slog.LevelError},
+		{"http", slog.LevelWarn},
+	}
+	for _, tt := range tests {
+		if got := levels.lookup(tt.name); got != tt.level {
+			t.Errorf("lookup(%q) = %v, want %v", tt.name, got, tt.level)
+		}
+	}
+
+	for _, s := range []string{"db", "=debug", "db=unknown"} {
+		if _, err := ParseNamedLevels(s); err == nil {
+			t.Errorf("want error for %q", s)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		s     string
+		level slog.Level
+	}{
+		{"debug", slog.LevelDebug},
+		{"INFO+1", slog.LevelInfo + 1},
+		{" warn ", slog.LevelWarn},
+		{"error", slog.LevelError},
+		{"trace", LevelTrace},
+		{"Notice", LevelNotice},
+		{"CRITICAL", LevelCritical},
+		{"panic", LevelPanic},
+		{"FATAL+2", LevelFatal + 2},
+		{"trace-1", LevelTrace - 1},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.s)
+		if err != nil {
+			t.Errorf("ParseLevel(%q) error: %v", tt.s, err)
+			continue
+		}
+		if got != tt.level {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tt.s, got, tt.level)
+		}
+	}
+	for _, s := range []string{"", "unknown", "fatal+x"} {
+		if _, err := ParseLevel(s); err == nil {
+			t.Errorf("want error for %q", s)
+		}
+	}
+}
+
+func TestLevelString(t *testing.T) {
+	for _, test := range []struct {
+		level    slog.Level
+		expected string
+	}{
+		{LevelTrace - 1, "DEBUG-5"},
+		{LevelTrace, "TRACE"},
+		{slog.LevelDebug - 1, "DEBUG-1"},
+		{slog.LevelDebug, "DEBUG"},
+		{slog.LevelInfo + 1, "INFO+1"},
+		{LevelNotice, "NOTICE"},
+		{slog.LevelWarn, "WARN"},
+		{slog.LevelError, "ERROR"},
+		{LevelCritical + 1, "ERROR+3"},
+		{LevelPanic, "PANIC"},
+		{LevelFatal, "FATAL"},
+		{LevelFatal + 1, "ERROR+9"},
+	} {
+		if got := LevelString(test.level); got != test.expected {
+			t.Errorf("LevelString(%d) = %q, want %q", test.level, got, test.expected)
+		}
+	}
+}
+
+func TestLevelNames(t *testing.T) {
+	names := LevelNames{
+		LevelTrace:         "FINEST",
+		LevelTrace + 1:     "TRACE+1",
+		slog.LevelInfo + 1: "VERBOSE",
+	}
+	for _, test := range []struct {
+		level    slog.Level
+		expected string
+	}{
+		{LevelTrace, "FINEST"},
+		{LevelTrace + 1, "TRACE+1"},
+		{LevelTrace - 1, "DEBUG-5"},
+		{slog.LevelDebug, "DEBUG"},
+		{slog.LevelInfo + 1, "VERBOSE"},
+		{LevelNotice, "NOTICE"},
+		{LevelNotice + 1, "INFO+3"},
+		{slog.LevelWarn, "WARN"},
+		{slog.LevelError + 1, "ERROR+1"},
+		{LevelCritical, "CRITICAL"},
+		{LevelPanic, "PANIC"},
+		{LevelFatal, "FATAL"},
+	} {
+		if got := names.name(test.level); got != test.expected {
+			t.Errorf("got %q, want %q", got, test.expected)
+		}
+		// the names round trip
+		if l, err := names.Parse(test.expected); err != nil || l != test.level {
+			t.Errorf("parse %q: got %v %v, want %v", test.expected, l, err, test.level)
+		}
+	}
+
+	if l, err := names.Parse("verbose"); err != nil || l != slog.LevelInfo+1 {
+		t.Errorf("got %v %v, want %v", l, err, slog.LevelInfo+1)
+	}
+	if l, err := names.Parse("critical"); err != nil || l != LevelCritical {
+		t.Errorf("got %v %v, want %v", l, err, LevelCritical)
+	}
+
+	level := NewAtomicLevel(LevelTrace)
+	level.SetLevelNames(names)
+	if level.String() != "FINEST" {
+		t.Errorf("got %q, want %q", level.String(), "FINEST")
+	}
+	if err := level.UnmarshalText([]byte("verbose")); err != nil || level.Level() != slog.LevelInfo+1 {
+		t.Errorf("got %v %v, want %v", level.Level(), err, slog.LevelInfo+1)
+	}
+	text, _ := level.MarshalText()
+	if string(text) != "VERBOSE" {
+		t.Errorf("got %s, want %s", text, "VERBOSE")
+	}
+}
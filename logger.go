@@ -4,21 +4,55 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"os"
 	"runtime"
 	"time"
 
 	"github.com/icefed/zlog/buffer"
 )
 
+// PCCapturer is an optional interface that a slog.Handler can implement,
+// Logger calls CapturePC to know whether the source's pc is needed in the slog.Record.
+// If the handler does not implement it, the pc is always captured.
+type PCCapturer interface {
+	CapturePC(level slog.Level) bool
+}
+
+// OptionsApplier is an optional interface that a slog.Handler can implement
+// to support Logger.WithOptions. ApplyOptions returns a new handler with the given options,
+// if the handler does not implement it, Logger.WithOptions does nothing.
+type OptionsApplier interface {
+	ApplyOptions(opts ...Option) slog.Handler
+}
+
+// NamedHandler is an optional interface that a slog.Handler can implement
+// to support Logger.Named. WithName returns a new handler with the given full
+// logger name, if the handler does not implement it, the name is ignored.
+type NamedHandler interface {
+	WithName(name string) slog.Handler
+}
+
+// Syncer is an optional interface that a slog.Handler can implement to flush
+// its buffered writers, Logger.Sync calls it, and Fatal calls it before the
+// process exits.
+type Syncer interface {
+	Sync() error
+}
+
+// Logger provides the printf-style logging methods on top of a slog.Handler.
 type Logger struct {
-	h *JSONHandler
+	h slog.Handler
 
+	name       string
 	callerSkip int
+	exitFunc   func(code int)
+	// ctx is used by the methods without a context parameter, see WithContext.
+	ctx context.Context
 }
 
 // New creates a new Logger. NewJSONHandler(nil) will be used if h is nil.
-func New(h *JSONHandler) *Logger {
-	if h == nil {
+func New(h slog.Handler) *Logger {
+	if isNil(h) {
 		h = NewJSONHandler(nil)
 	}
 	l := &Logger{
@@ -31,7 +65,10 @@ func New(h *JSONHandler) *Logger {
 func (l *Logger) clone() *Logger {
 	return &Logger{
 		h:          l.h,
+		name:       l.name,
 		callerSkip: l.callerSkip,
+		exitFunc:   l.exitFunc,
+		ctx:        l.ctx,
 	}
 }
 
@@ -49,20 +86,52 @@ func (l *Logger) With(args ...any) *Logger {
 		return l
 	}
 	newLogger := l.clone()
-	newLogger.h = l.h.WithAttrs(argsToAttrs(args...)).(*JSONHandler)
+	newLogger.h = l.h.WithAttrs(argsToAttrs(args...))
 	return newLogger
 }
 
-// WithContext returns a new logger with the given handler options.
+// WithOptions returns a new logger with the given handler options.
+// The options take effect only if the handler implements OptionsApplier.
 func (l *Logger) WithOptions(opts ...Option) *Logger {
 	if l == nil {
 		return l
 	}
+	oa, ok := l.h.(OptionsApplier)
+	if !ok {
+		return l
+	}
+	newLogger := l.clone()
+	newLogger.h = oa.ApplyOptions(opts...)
+	return newLogger
+}
+
+// Named returns a new logger with the name appended to the logger's name,
+// separated by a ".", e.g. New(h).Named("db").Named("pool") is named "db.pool".
+// The name is written by handlers implementing NamedHandler.
+func (l *Logger) Named(name string) *Logger {
+	if l == nil || name == "" {
+		return l
+	}
 	newLogger := l.clone()
-	newLogger.h = l.h.WithOptions(opts...)
+	if l.name == "" {
+		newLogger.name = name
+	} else {
+		newLogger.name = l.name + "." + name
+	}
+	if nh, ok := l.h.(NamedHandler); ok {
+		newLogger.h = nh.WithName(newLogger.name)
+	}
 	return newLogger
 }
 
+// Name returns the logger's name.
+func (l *Logger) Name() string {
+	if l == nil {
+		return ""
+	}
+	return l.name
+}
+
 // WithCallerSkip returns a new logger with the given caller skip.
 // argument 'skip' will be added to the caller skip in the logger, which is passed
 // as the first parameter 'skip' when calling runtime.Callers to get the source's pc.
@@ -77,6 +146,41 @@ func (l *Logger) WithCallerSkip(skip int) *Logger {
 	return newLogger
 }
 
+// WithExitFunc returns a new logger with the given exit function, which is called
+// with exit code 1 by Fatal after the record is logged, default is os.Exit.
+// It is useful to test the Fatal paths.
+func (l *Logger) WithExitFunc(exit func(code int)) *Logger {
+	if l == nil {
+		return l
+	}
+	newLogger := l.clone()
+	newLogger.exitFunc = exit
+	return newLogger
+}
+
+// WithContext returns a new logger bound to ctx, the methods without a context
+// parameter such as Info log with ctx, so the attributes of NewContext and
+// ContextExtractors work with them.
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	if l == nil {
+		return l
+	}
+	newLogger := l.clone()
+	newLogger.ctx = ctx
+	return newLogger
+}
+
+// context returns ctx if it is not nil, otherwise the bound context of the logger.
+func (l *Logger) context(ctx context.Context) context.Context {
+	if ctx != nil {
+		return ctx
+	}
+	if l != nil && l.ctx != nil {
+		return l.ctx
+	}
+	return context.Background()
+}
+
 var badKey = "!BADKEY"
 
 func argsToAttrs(args ...any) []slog.Attr {
@@ -108,46 +212,70 @@ func (l *Logger) WithGroup(name string) *Logger {
 		return l
 	}
 	newLogger := l.clone()
-	newLogger.h = l.h.WithGroup(name).(*JSONHandler)
+	newLogger.h = l.h.WithGroup(name)
 	return newLogger
 }
 
+// Sync flushes the buffered writers of the handler, if the handler implements Syncer.
+// Applications should call Sync before exiting if the writers are buffered, e.g. AsyncWriter.
+func (l *Logger) Sync() error {
+	if l == nil {
+		return nil
+	}
+	if s, ok := l.h.(Syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
 // Handler returns the handler.
-func (l *Logger) Handler() *JSONHandler {
+func (l *Logger) Handler() slog.Handler {
 	if l == nil {
 		return nil
 	}
 	return l.h
 }
 
+// capturePC reports whether the pc should be set in the slog.Record at the given level.
+func (l *Logger) capturePC(level slog.Level) bool {
+	if c, ok := l.h.(PCCapturer); ok {
+		return c.CapturePC(level)
+	}
+	return true
+}
+
 func (l *Logger) log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	ctx = l.context(ctx)
 	if !l.Enabled(ctx, level) {
 		return
 	}
+	l.output(ctx, level, msg, args...)
+}
+
+// output is called by log and logf, so they have the same depth of callers.
+func (l *Logger) output(ctx context.Context, level slog.Level, msg string, args ...any) {
 	var pc uintptr
-	if l.h.CapturePC(level) {
+	if l.capturePC(level) {
 		var pcs [1]uintptr
-		// skip runtime.Callers, log, log's caller, and l.callerSkip
-		runtime.Callers(3+l.callerSkip, pcs[:])
+		// skip runtime.Callers, output, log or logf, their caller, and l.callerSkip
+		runtime.Callers(4+l.callerSkip, pcs[:])
 		pc = pcs[0]
 	}
 
 	r := slog.NewRecord(time.Now(), level, msg, pc)
 	r.Add(args...)
 
-	if ctx == nil {
-		ctx = context.Background()
-	}
-	// whether error needs to be handled
+	// write errors are reported by the handler, see Config.OnWriteError
 	_ = l.h.Handle(ctx, r)
 }
 
 func (l *Logger) logAttrs(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	ctx = l.context(ctx)
 	if !l.Enabled(ctx, level) {
 		return
 	}
 	var pc uintptr
-	if l.h.CapturePC(level) {
+	if l.capturePC(level) {
 		var pcs [1]uintptr
 		// skip runtime.Callers, logAttrs, logAttrs's caller, and l.callerSkip
 		runtime.Callers(3+l.callerSkip, pcs[:])
@@ -156,14 +284,12 @@ func (l *Logger) logAttrs(ctx context.Context, level slog.Level, msg string, att
 	r := slog.NewRecord(time.Now(), level, msg, pc)
 	r.AddAttrs(attrs...)
 
-	if ctx == nil {
-		ctx = context.Background()
-	}
-	// whether error needs to be handled
+	// write errors are reported by the handler, see Config.OnWriteError
 	_ = l.h.Handle(ctx, r)
 }
 
 func (l *Logger) logf(ctx context.Context, level slog.Level, format string, args ...any) {
+	ctx = l.context(ctx)
 	if !l.Enabled(ctx, level) {
 		return
 	}
@@ -171,7 +297,19 @@ func (l *Logger) logf(ctx context.Context, level slog.Level, format string, args
 	buf := buffer.New()
 	defer buf.Free()
 	*buf = fmt.Appendf(*buf, format, args...)
-	l.log(ctx, level, buf.String())
+	l.output(ctx, level, buf.String())
+}
+
+// exit syncs the handler, then calls the exit function with code 1.
+func (l *Logger) exit() {
+	exit := os.Exit
+	if l != nil {
+		_ = l.Sync()
+		if l.exitFunc != nil {
+			exit = l.exitFunc
+		}
+	}
+	exit(1)
 }
 
 // Log prints log as a JSON object on a single line with the given level and message.
@@ -208,12 +346,12 @@ func (l *Logger) DebugContextf(ctx context.Context, format string, args ...any)
 
 // Info prints log message at the info level.
 func (l *Logger) Info(msg string, args ...any) {
-	l.log(context.Background(), slog.LevelInfo, msg, args...)
+	l.log(nil, slog.LevelInfo, msg, args...)
 }
 
 // Infof prints log message at the info level, fmt.Sprintf is used to format.
 func (l *Logger) Infof(format string, args ...any) {
-	l.logf(context.Background(), slog.LevelInfo, format, args...)
+	l.logf(nil, slog.LevelInfo, format, args...)
 }
 
 // InfoContext prints log message at the info level with context.
@@ -228,7 +366,7 @@ func (l *Logger) InfoContextf(ctx context.Context, format string, args ...any) {
 
 // Warn prints log message at the warn level.
 func (l *Logger) Warn(msg string, args ...any) {
-	l.log(context.Background(), slog.LevelWarn, msg, args...)
+	l.log(nil, slog.LevelWarn, msg, args...)
 }
 
 // Warnf prints log message at the warn level, fmt.Sprintf is used to format.
@@ -248,7 +386,7 @@ func (l *Logger) WarnContextf(ctx context.Context, format string, args ...any) {
 
 // Error prints log message at the error level.
 func (l *Logger) Error(msg string, args ...any) {
-	l.log(context.Background(), slog.LevelError, msg, args...)
+	l.log(nil, slog.LevelError, msg, args...)
 }
 
 // Errorf prints log message at the error level, fmt.Sprintf is used to format.
@@ -265,3 +403,38 @@ func (l *Logger) ErrorContext(ctx context.Context, msg string, args ...any) {
 func (l *Logger) ErrorContextf(ctx context.Context, format string, args ...any) {
 	l.logf(ctx, slog.LevelError, format, args...)
 }
+
+// Panic prints log message at the panic level, then panics with the message.
+func (l *Logger) Panic(msg string, args ...any) {
+	l.log(nil, LevelPanic, msg, args...)
+	panic(msg)
+}
+
+// Panicf prints log message at the panic level, then panics with the message,
+// fmt.Sprintf is used to format.
+func (l *Logger) Panicf(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	l.log(nil, LevelPanic, msg)
+	panic(msg)
+}
+
+// Fatal prints log message at the fatal level, calls Sync to flush the buffered
+// writers, then calls os.Exit(1), see WithExitFunc.
+func (l *Logger) Fatal(msg string, args ...any) {
+	l.log(nil, LevelFatal, msg, args...)
+	l.exit()
+}
+
+// Fatalf prints log message at the fatal level, calls Sync to flush the buffered
+// writers, then calls os.Exit(1), fmt.Sprintf is used to format.
+func (l *Logger) Fatalf(format string, args ...any) {
+	l.logf(nil, LevelFatal, format, args...)
+	l.exit()
+}
+
+// FatalContext prints log message at the fatal level with context, calls Sync to
+// flush the buffered writers, then calls os.Exit(1).
+func (l *Logger) FatalContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, LevelFatal, msg, args...)
+	l.exit()
+}
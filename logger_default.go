@@ -22,6 +22,16 @@ func WithGroup(name string) *Logger {
 	return defaultLogger.WithGroup(name)
 }
 
+// Named calls Logger.Named on the default logger.
+func Named(name string) *Logger {
+	return defaultLogger.Named(name)
+}
+
+// Sync calls Logger.Sync on the default logger.
+func Sync() error {
+	return defaultLogger.Sync()
+}
+
 // Log calls Logger.Log on the default logger.
 func Log(ctx context.Context, level slog.Level, msg string, args ...any) {
 	defaultLogger.Log(ctx, level, msg, args...)
@@ -111,3 +121,28 @@ func ErrorContext(ctx context.Context, msg string, args ...any) {
 func ErrorContextf(ctx context.Context, format string, args ...any) {
 	defaultLogger.ErrorContextf(ctx, format, args...)
 }
+
+// Panic calls Logger.Panic on the default logger.
+func Panic(msg string, args ...any) {
+	defaultLogger.Panic(msg, args...)
+}
+
+// Panicf calls Logger.Panicf on the default logger.
+func Panicf(format string, args ...any) {
+	defaultLogger.Panicf(format, args...)
+}
+
+// Fatal calls Logger.Fatal on the default logger.
+func Fatal(msg string, args ...any) {
+	defaultLogger.Fatal(msg, args...)
+}
+
+// Fatalf calls Logger.Fatalf on the default logger.
+func Fatalf(format string, args ...any) {
+	defaultLogger.Fatalf(format, args...)
+}
+
+// FatalContext calls Logger.FatalContext on the default logger.
+func FatalContext(ctx context.Context, msg string, args ...any) {
+	defaultLogger.FatalContext(ctx, msg, args...)
+}
@@ -0,0 +1,62 @@
+package zlog
+
+import (
+	"log"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// NewStdLogger returns a *log.Logger of the standard library that writes to l at the level,
+// each line is logged as a record with the line as the message.
+// The source and stack trace point at the caller of the *log.Logger methods.
+func NewStdLogger(l *Logger, level slog.Level) *log.Logger {
+	return log.New(&stdLogWriter{l: l, level: level}, "", 0)
+}
+
+// RedirectStdLog redirects the output of the log package's default logger to l at
+// the info level, it returns a function to restore the original output, flags and prefix.
+func RedirectStdLog(l *Logger) func() {
+	return RedirectStdLogAt(l, slog.LevelInfo)
+}
+
+// RedirectStdLogAt is like RedirectStdLog, but logs at the given level.
+func RedirectStdLogAt(l *Logger, level slog.Level) func() {
+	flags, prefix, w := log.Flags(), log.Prefix(), log.Writer()
+	log.SetFlags(0)
+	log.SetPrefix("")
+	log.SetOutput(&stdLogWriter{l: l, level: level})
+	return func() {
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+		log.SetOutput(w)
+	}
+}
+
+// stdLogWriter converts the lines written by *log.Logger to records.
+type stdLogWriter struct {
+	l     *Logger
+	level slog.Level
+}
+
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	ctx := w.l.context(nil)
+	if !w.l.Enabled(ctx, w.level) {
+		return len(p), nil
+	}
+	var pc uintptr
+	if w.l.capturePC(w.level) {
+		var pcs [1]uintptr
+		// skip runtime.Callers, Write, log.(*Logger).output, the log function
+		// or method such as log.Printf, and l.callerSkip
+		runtime.Callers(4+w.l.callerSkip, pcs[:])
+		pc = pcs[0]
+	}
+	msg := strings.TrimSuffix(string(p), "\n")
+	r := slog.NewRecord(time.Now(), w.level, msg, pc)
+	if err := w.l.h.Handle(ctx, r); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,103 @@
+package zlog
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNewStdLogger(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := New(NewJSONHandler(&Config{
+		HandlerOptions: slog.HandlerOptions{
+			AddSource: true,
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		},
+		Writer: buf,
+	}))
+
+	check := func(expected string) {
+		t.Helper()
+		if got := strings.TrimSuffix(buf.String(), "\n"); got != expected {
+			t.Errorf("got %q, want %q", got, expected)
+		}
+		buf.Reset()
+	}
+
+	stdLog := NewStdLogger(l, slog.LevelWarn)
+	stdLog.Printf("hello %s", "world")
+	check(`{"level":"WARN","source":"` + getCallerLineSource(-1) + `","msg":"hello world"}`)
+	stdLog.Println("hello", "world")
+	check(`{"level":"WARN","source":"` + getCallerLineSource(-1) + `","msg":"hello world"}`)
+	stdLog.Print("multiple\nlines\n")
+	check(`{"level":"WARN","source":"` + getCallerLineSource(-1) + `","msg":"multiple\nlines"}`)
+
+	NewStdLogger(l, slog.LevelDebug).Print("disabled")
+	check("")
+
+	// stack trace starts from the caller
+	NewStdLogger(l.WithOptions(WithAddSource(false), WithStacktraceEnabled(true)), slog.LevelError).Print("error")
+	want := `{"level":"ERROR","msg":"error","stacktrace":"github.com/icefed/zlog.TestNewStdLogger\n\t`
+	if got := buf.String(); !strings.HasPrefix(got, want) {
+		t.Errorf("got %q, want prefix %q", got, want)
+	}
+	buf.Reset()
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := New(NewTextHandler(&Config{
+		HandlerOptions: slog.HandlerOptions{
+			AddSource: true,
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		},
+		Writer: buf,
+	}))
+
+	origWriter := bytes.NewBuffer(nil)
+	flags := log.Flags()
+	log.SetOutput(origWriter)
+	log.SetPrefix("test: ")
+	log.SetFlags(log.Lmsgprefix)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetPrefix("")
+		log.SetFlags(flags)
+	}()
+
+	restore := RedirectStdLog(l)
+	log.Printf("hello %s", "world")
+	if got, want := buf.String(), "level=INFO source="+getCallerLineSource(-1)+" msg=\"hello world\"\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	buf.Reset()
+
+	restoreAt := RedirectStdLogAt(l, slog.LevelError)
+	log.Print("error")
+	if got, want := buf.String(), "level=ERROR source="+getCallerLineSource(-1)+" msg=error\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	restoreAt()
+	restore()
+	log.Print("restored")
+	if got := origWriter.String(); got != "test: restored\n" {
+		t.Errorf("got %q, want %q", got, "test: restored\n")
+	}
+	if log.Flags() != log.Lmsgprefix || log.Prefix() != "test: " {
+		t.Error("want flags and prefix restored")
+	}
+}
@@ -110,6 +110,217 @@ func getCallerLineSource(adjustLines int) string {
 	source := buildSource(pc)
 	source.Line = source.Line + adjustLines
 	buf := buffer.New()
-	formatSourceValue(buf, source)
+	formatSourceValue(buf, CallerShort, source)
 	return buf.String()
 }
+
+func TestLoggerWithSlogHandler(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := New(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		AddSource: true,
+		Level:     slog.LevelDebug,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			switch a.Key {
+			case slog.TimeKey:
+				return slog.Attr{}
+			case slog.SourceKey:
+				s := a.Value.Any().(*slog.Source)
+				a.Value = slog.StringValue(fmt.Sprintf("%s:%d", s.Function, s.Line))
+			}
+			return a
+		},
+	}))
+	if _, ok := l.Handler().(*slog.TextHandler); !ok {
+		t.Errorf("got %T, want *slog.TextHandler", l.Handler())
+	}
+
+	check := func(expected string) {
+		t.Helper()
+		got := buf.String()
+		// Remove the trailing newline
+		got = got[:len(got)-1]
+		if got != expected {
+			t.Errorf("got %q, want %q", got, expected)
+		}
+		buf.Reset()
+	}
+
+	// WithOptions is ignored by handlers not implementing OptionsApplier
+	l = l.WithOptions(WithAddSource(false))
+	l.WithGroup("g").With("app", "test").Info("hello world")
+	_, _, line, _ := runtime.Caller(0)
+	check(fmt.Sprintf(`level=INFO source=github.com/icefed/zlog.TestLoggerWithSlogHandler:%d msg="hello world" g.app=test`, line-1))
+}
+
+func TestLoggerNamed(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	levels, _ := ParseNamedLevels("db=debug,http=warn")
+	h := NewJSONHandler(&Config{
+		HandlerOptions: slog.HandlerOptions{
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		},
+		Writer:      buf,
+		LoggerKey:   "name",
+		NamedLevels: levels,
+	})
+	l := New(h)
+
+	check := func(expected string) {
+		t.Helper()
+		got := buf.String()
+		if got != expected {
+			t.Errorf("got %q, want %q", got, expected)
+		}
+		buf.Reset()
+	}
+
+	pool := l.Named("db").Named("pool")
+	if pool.Name() != "db.pool" {
+		t.Errorf("got name %q, want %q", pool.Name(), "db.pool")
+	}
+	pool.Debug("hello world", "size", 10)
+	check(`{"level":"DEBUG","name":"db.pool","msg":"hello world","size":10}` + "\n")
+
+	l.Named("http").Info("hello world")
+	check("")
+	l.Named("http").With("path", "/").Warn("hello world")
+	check(`{"level":"WARN","name":"http","msg":"hello world","path":"/"}` + "\n")
+
+	l.Named("app").Debug("hello world")
+	check("")
+	l.Named("app").WithOptions(WithNamedLevels(NamedLevels{"app": slog.LevelDebug})).Debug("hello world")
+	check(`{"level":"DEBUG","name":"app","msg":"hello world"}` + "\n")
+
+	// names are ignored by handlers not implementing NamedHandler
+	l = New(slog.NewJSONHandler(buf, nil)).Named("db")
+	if l.Name() != "db" {
+		t.Errorf("got name %q, want %q", l.Name(), "db")
+	}
+}
+
+type flushWriter struct {
+	bytes.Buffer
+	flushed bool
+}
+
+func (w *flushWriter) Flush(context.Context) error {
+	w.flushed = true
+	return nil
+}
+
+func TestLoggerFatal(t *testing.T) {
+	w := &flushWriter{}
+	var code int
+	l := New(NewJSONHandler(&Config{
+		HandlerOptions: slog.HandlerOptions{
+			AddSource: true,
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		},
+		Writer: w,
+	})).WithExitFunc(func(c int) {
+		code = c
+	})
+
+	check := func(expected string) {
+		t.Helper()
+		if got := w.String(); got != expected+"\n" {
+			t.Errorf("got %q, want %q", got, expected)
+		}
+		if code != 1 || !w.flushed {
+			t.Errorf("got code %v flushed %v, want code 1 flushed", code, w.flushed)
+		}
+		w.Reset()
+		code, w.flushed = 0, false
+	}
+
+	l.Fatal("fatal", "key", "value")
+	check(`{"level":"FATAL","source":"` + getCallerLineSource(-1) + `","msg":"fatal","key":"value"}`)
+	l.Fatalf("fatalf: %s", "value")
+	check(`{"level":"FATAL","source":"` + getCallerLineSource(-1) + `","msg":"fatalf: value"}`)
+	l.FatalContext(context.Background(), "fatal", "key", "value")
+	check(`{"level":"FATAL","source":"` + getCallerLineSource(-1) + `","msg":"fatal","key":"value"}`)
+	l.Infof("infof: %s", "value")
+	if want := `{"level":"INFO","source":"` + getCallerLineSource(-1) + `","msg":"infof: value"}` + "\n"; w.String() != want {
+		t.Errorf("got %q, want %q", w.String(), want)
+	}
+	w.Reset()
+
+	// exits even if the fatal level is disabled
+	l.WithOptions(WithLevel(LevelFatal + 1)).Fatal("fatal")
+	if w.Len() != 0 || code != 1 {
+		t.Errorf("got %q code %v, want empty output and code 1", w.String(), code)
+	}
+}
+
+func TestLoggerPanic(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := New(NewTextHandler(&Config{
+		HandlerOptions: slog.HandlerOptions{
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		},
+		Writer: buf,
+	}))
+
+	testPanic := func(f func(), expectedPanic, expected string) {
+		t.Helper()
+		defer func() {
+			t.Helper()
+			if r := recover(); r != expectedPanic {
+				t.Errorf("got panic %v, want %v", r, expectedPanic)
+			}
+			if got := buf.String(); got != expected+"\n" {
+				t.Errorf("got %q, want %q", got, expected)
+			}
+			buf.Reset()
+		}()
+		f()
+	}
+	testPanic(func() { l.Panic("panic", "key", "value") }, "panic", `level=PANIC msg=panic key=value`)
+	testPanic(func() { l.Panicf("panicf: %s", "value") }, "panicf: value", `level=PANIC msg="panicf: value"`)
+}
+
+func TestLoggerSync(t *testing.T) {
+	sw1, sw2 := &syncWriter{}, &syncWriter{}
+	h := NewMultiHandler(
+		NewSamplingHandler(NewJSONHandler(&Config{Writer: sw1}), nil),
+		NewTextHandler(&Config{Writer: sw2}),
+		slog.NewJSONHandler(sw2, nil),
+	)
+	l := New(h)
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if sw1.synced != 1 || sw2.synced != 1 {
+		t.Errorf("got synced %d %d, want 1 1", sw1.synced, sw2.synced)
+	}
+
+	sw2.err = fmt.Errorf("sync failed")
+	SetDefault(l)
+	defer SetDefault(New(NewJSONHandler(nil)))
+	if err := Sync(); err == nil || err.Error() != "sync failed" {
+		t.Errorf("got err %v, want %v", err, sw2.err)
+	}
+
+	if err := New(slog.NewJSONHandler(sw1, nil)).Sync(); err != nil {
+		t.Errorf("got err %v, want nil for handler not implementing Syncer", err)
+	}
+	var nilLogger *Logger
+	if err := nilLogger.Sync(); err != nil {
+		t.Errorf("got err %v, want nil", err)
+	}
+}
@@ -0,0 +1,51 @@
+package zlog
+
+import "log/slog"
+
+// KeyMapping renames the built-in attributes by key, or drops them if the new key
+// is empty, without ReplaceAttr, e.g.
+//
+//	zlog.KeyMapping{
+//		slog.TimeKey:   "ts",
+//		slog.SourceKey: "",
+//	}
+//
+// The built-in attributes are the time, level, message, logger name, source, function
+// and stack trace, matched by the keys after TimeKey, LevelKey, MessageKey, etc. are
+// applied, the attributes added by the user are never mapped. ReplaceAttr is not
+// called for the built-in attributes in the KeyMapping, they are written by the fast
+// path without allocations even if ReplaceAttr is set, ReplaceAttr is still called
+// for the other built-in attributes and all the attributes added by the user.
+type KeyMapping map[string]string
+
+// mapKey returns the new key of the key, keep is false if the attribute is dropped,
+// mapped is true if the key is in the mapping, so ReplaceAttr is skipped.
+func (m KeyMapping) mapKey(key string) (newKey string, keep, mapped bool) {
+	if newKey, ok := m[key]; ok {
+		return newKey, newKey != "", true
+	}
+	return key, true, false
+}
+
+// ValueMapping maps the values of the built-in attributes without ReplaceAttr.
+type ValueMapping struct {
+	// LowercaseLevel writes the level names in lower case, e.g. "info", "warn+2".
+	LowercaseLevel bool
+	// SourceAsString writes the source as a string in CallerFormat, even if
+	// Config.SourceObject is set, e.g. by a preset.
+	SourceAsString bool
+}
+
+// appendLevelName appends the name of the level in names, in lower case if lower is true.
+func appendLevelName(buf []byte, names LevelNames, l slog.Level, lower bool) []byte {
+	start := len(buf)
+	buf = append(buf, names.name(l)...)
+	if lower {
+		for i := start; i < len(buf); i++ {
+			if c := buf[i]; 'A' <= c && c <= 'Z' {
+				buf[i] = c + 'a' - 'A'
+			}
+		}
+	}
+	return buf
+}
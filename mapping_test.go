@@ -0,0 +1,225 @@
+package zlog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeyMapping(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	h := NewJSONHandler(&Config{
+		HandlerOptions: slog.HandlerOptions{
+			AddSource: true,
+		},
+		Writer: buf,
+		KeyMapping: KeyMapping{
+			slog.TimeKey:    "",
+			slog.LevelKey:   "lvl",
+			slog.MessageKey: "message",
+			slog.SourceKey:  "",
+			"logger":        "name",
+		},
+	})
+	tests := []struct {
+		name     string
+		h        slog.Handler
+		expected string
+	}{
+		{
+			name:     "json",
+			h:        h.WithAttrs([]slog.Attr{slog.String("level", "attr")}),
+			expected: `{"lvl":"INFO","name":"app","message":"test","level":"attr","time":"t","source":"s"}`,
+		}, {
+			name:     "json group",
+			h:        h.WithGroup("g"),
+			expected: `{"lvl":"INFO","name":"app","message":"test","g":{"time":"t","source":"s"}}`,
+		}, {
+			name:     "text",
+			h:        NewTextHandler(h.c),
+			expected: `lvl=INFO name=app message=test time=t source=s`,
+		}, {
+			name:     "development",
+			h:        h.WithOptions(WithDevelopment(true)),
+			expected: "INFO\tapp\ttest\t{\"time\":\"t\",\"source\":\"s\"}",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf.Reset()
+			l := New(test.h).Named("app")
+			// the attributes added by the user are not mapped
+			l.Info("test", "time", "t", "source", "s")
+			if got := strings.TrimSuffix(buf.String(), "\n"); got != test.expected {
+				t.Errorf("got %q, want %q", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestKeyMappingWithReplaceAttr(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	var keys []string
+	h := NewJSONHandler(&Config{
+		HandlerOptions: slog.HandlerOptions{
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				keys = append(keys, a.Key)
+				if a.Key == "message" || a.Key == "logger" {
+					a.Value = slog.StringValue(strings.ToUpper(a.Value.String()))
+				}
+				return a
+			},
+		},
+		Writer: buf,
+		KeyMapping: KeyMapping{
+			slog.TimeKey:    "",
+			slog.LevelKey:   "",
+			slog.MessageKey: "message",
+		},
+	})
+	New(h).Named("app").Info("test", "k", "v")
+	expected := `{"logger":"APP","message":"test","k":"v"}`
+	if got := strings.TrimSuffix(buf.String(), "\n"); got != expected {
+		t.Errorf("got %s, want %s", got, expected)
+	}
+	// ReplaceAttr is not called for the built-in attributes in the KeyMapping.
+	if got := strings.Join(keys, ","); got != "logger,k" {
+		t.Errorf("got keys %s, want logger,k", got)
+	}
+}
+
+func TestValueMapping(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	h := NewJSONHandler(&Config{
+		HandlerOptions: slog.HandlerOptions{
+			AddSource: true,
+		},
+		Writer: buf,
+	}).WithOptions(
+		WithECSFormat(),
+		WithKeyMapping(KeyMapping{"@timestamp": ""}),
+		WithValueMapping(ValueMapping{LowercaseLevel: true, SourceAsString: true}),
+		WithLevelNames(LevelNames{LevelNotice: "NOTICE"}),
+	)
+	_, file, _, _ := runtime.Caller(0)
+	file = shortFile(file)
+	tests := []struct {
+		name     string
+		h        slog.Handler
+		level    slog.Level
+		expected string
+	}{
+		{
+			name:     "json",
+			h:        h,
+			level:    slog.LevelWarn + 2,
+			expected: `{"log.level":"warn+2","log.origin":"` + file + `:N","message":"test","level":"notice"}`,
+		}, {
+			name:     "text",
+			h:        NewTextHandler(h.c),
+			level:    LevelNotice,
+			expected: `log.level=notice log.origin=` + file + `:N message=test level=notice`,
+		}, {
+			name:     "development",
+			h:        h.WithOptions(WithDevelopment(true)),
+			level:    slog.LevelError,
+			expected: "error\t" + file + ":N\ttest\t{\"level\":\"notice\"}",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf.Reset()
+			New(test.h).Log(context.Background(), test.level, "test", "level", LevelNotice)
+			got := lineRegexp.ReplaceAllString(strings.TrimSuffix(buf.String(), "\n"), ".go:N")
+			if got != test.expected {
+				t.Errorf("got %q, want %q", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestKeyMappingAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool allocates under the race detector")
+	}
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "test", 0)
+	r.AddAttrs(slog.String("k", "v"), slog.Int("n", 1), slog.Bool("b", true))
+	allocs := func(h slog.Handler) float64 {
+		return testing.AllocsPerRun(100, func() {
+			_ = h.Handle(context.Background(), r)
+		})
+	}
+
+	mapping := &Config{
+		Writer:       io.Discard,
+		KeyMapping:   KeyMapping{slog.TimeKey: "ts", slog.MessageKey: "message", slog.LevelKey: ""},
+		ValueMapping: ValueMapping{LowercaseLevel: true},
+	}
+	tests := []struct {
+		name    string
+		base    slog.Handler
+		mapping slog.Handler
+	}{
+		{
+			name:    "json",
+			base:    NewJSONHandler(&Config{Writer: io.Discard}),
+			mapping: NewJSONHandler(mapping),
+		}, {
+			name:    "text",
+			base:    NewTextHandler(&Config{Writer: io.Discard}),
+			mapping: NewTextHandler(mapping),
+		}, {
+			// ReplaceAttr is skipped for the mapped built-in attributes
+			name:    "json with ReplaceAttr",
+			base:    NewJSONHandler(&Config{Writer: io.Discard}),
+			mapping: NewJSONHandler(mapping).WithOptions(WithReplaceAttr(keepAttr)),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			base := allocs(test.base)
+			if got := allocs(test.mapping); got > base {
+				t.Errorf("got %v allocs with mapping, want at most %v", got, base)
+			}
+		})
+	}
+}
+
+// keepAttr is a ReplaceAttr that keeps the attributes.
+func keepAttr(_ []string, a slog.Attr) slog.Attr {
+	return a
+}
+
+func BenchmarkKeyMapping(b *testing.B) {
+	keyMapping := KeyMapping{slog.TimeKey: "ts", slog.MessageKey: "message", slog.LevelKey: "severity"}
+	tests := []struct {
+		name string
+		c    *Config
+	}{
+		{"none", &Config{}},
+		{"KeyMapping", &Config{KeyMapping: keyMapping}},
+		{"KeyMapping with ReplaceAttr", &Config{
+			HandlerOptions: slog.HandlerOptions{ReplaceAttr: keepAttr},
+			KeyMapping:     keyMapping,
+		}},
+		{"ReplaceAttr", &Config{HandlerOptions: slog.HandlerOptions{ReplaceAttr: keepAttr}}},
+	}
+	for _, test := range tests {
+		b.Run(test.name, func(b *testing.B) {
+			test.c.Writer = io.Discard
+			h := NewJSONHandler(test.c)
+			r := slog.NewRecord(time.Now(), slog.LevelInfo, "test", 0)
+			r.AddAttrs(slog.String("k", "v"), slog.Int("n", 1))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = h.Handle(context.Background(), r)
+			}
+		})
+	}
+}
@@ -0,0 +1,5 @@
+//go:build !race
+
+package zlog
+
+const raceEnabled = false
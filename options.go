@@ -3,6 +3,8 @@ package zlog
 import (
 	"io"
 	"log/slog"
+	"maps"
+	"slices"
 	"time"
 )
 
@@ -54,6 +56,14 @@ func WithWriter(w io.Writer) Option {
 	}}
 }
 
+// WithConcurrentSafeWriter sets whether the writer is safe for concurrent use,
+// if true, writes will not be serialized by the handler.
+func WithConcurrentSafeWriter(safe bool) Option {
+	return optionFunc{func(c *Config) {
+		c.ConcurrentSafeWriter = safe
+	}}
+}
+
 // WithTimeFormatter sets the time formatter.
 func WithTimeFormatter(formatter func([]byte, time.Time) []byte) Option {
 	return optionFunc{func(c *Config) {
@@ -84,9 +94,135 @@ func WithStacktraceKey(key string) Option {
 	}}
 }
 
+// WithStacktraceMaxDepth sets the maximum number of frames in stack trace.
+func WithStacktraceMaxDepth(depth int) Option {
+	return optionFunc{func(c *Config) {
+		c.StacktraceMaxDepth = depth
+	}}
+}
+
+// WithStacktraceSkipPrefixes sets the packages or function name prefixes to skip in stack trace.
+func WithStacktraceSkipPrefixes(prefixes ...string) Option {
+	return optionFunc{func(c *Config) {
+		c.StacktraceSkipPrefixes = slices.Clone(prefixes)
+	}}
+}
+
+// WithStacktraceTrimPaths sets the prefixes to trim from the file paths in stack trace.
+func WithStacktraceTrimPaths(prefixes ...string) Option {
+	return optionFunc{func(c *Config) {
+		c.StacktraceTrimPaths = slices.Clone(prefixes)
+	}}
+}
+
+// WithStacktraceCollapse enables collapsing the consecutive same frames in stack trace.
+func WithStacktraceCollapse(enabled bool) Option {
+	return optionFunc{func(c *Config) {
+		c.StacktraceCollapse = enabled
+	}}
+}
+
+// WithStacktraceFrames enables writing stack trace as an array of frames in JSONHandler.
+func WithStacktraceFrames(enabled bool) Option {
+	return optionFunc{func(c *Config) {
+		c.StacktraceFrames = enabled
+	}}
+}
+
 // WithContextExtractor adds context extractors.
 func WithContextExtractor(extractors ...ContextExtractor) Option {
 	return optionFunc{func(c *Config) {
 		c.ContextExtractors = append(c.ContextExtractors, extractors...)
 	}}
 }
+
+// WithLoggerKey sets the key for logger name field.
+func WithLoggerKey(key string) Option {
+	return optionFunc{func(c *Config) {
+		c.LoggerKey = key
+	}}
+}
+
+// WithFunctionKey sets the key for caller function name field, empty key disables the field.
+func WithFunctionKey(key string) Option {
+	return optionFunc{func(c *Config) {
+		c.FunctionKey = key
+	}}
+}
+
+// WithCallerFormat sets the format of the source value.
+func WithCallerFormat(format CallerFormat) Option {
+	return optionFunc{func(c *Config) {
+		c.CallerFormat = format
+	}}
+}
+
+// WithSourceObject sets the keys to write the source as an object, nil writes the source as a string.
+func WithSourceObject(o *SourceObject) Option {
+	return optionFunc{func(c *Config) {
+		c.SourceObject = o.clone()
+	}}
+}
+
+// WithKeyMapping sets the mapping to rename or drop attributes by key.
+func WithKeyMapping(m KeyMapping) Option {
+	return optionFunc{func(c *Config) {
+		c.KeyMapping = maps.Clone(m)
+	}}
+}
+
+// WithValueMapping sets the mapping of the built-in attribute values.
+func WithValueMapping(m ValueMapping) Option {
+	return optionFunc{func(c *Config) {
+		c.ValueMapping = m
+	}}
+}
+
+// WithNamedLevels sets the levels for named loggers.
+func WithNamedLevels(levels NamedLevels) Option {
+	return optionFunc{func(c *Config) {
+		c.NamedLevels = maps.Clone(levels)
+	}}
+}
+
+// WithLevelNames sets the names of levels in the output.
+func WithLevelNames(names LevelNames) Option {
+	return optionFunc{func(c *Config) {
+		c.LevelNames = maps.Clone(names)
+	}}
+}
+
+// WithRedactor sets the redactor to mask sensitive values.
+func WithRedactor(r *Redactor) Option {
+	return optionFunc{func(c *Config) {
+		c.Redactor = r
+	}}
+}
+
+// WithRichErrors enables the rich error mode, see Config.RichErrors.
+func WithRichErrors(enabled bool) Option {
+	return optionFunc{func(c *Config) {
+		c.RichErrors = enabled
+	}}
+}
+
+// WithOnWriteError sets the callback to report write errors.
+func WithOnWriteError(f func(err error, suppressed uint64)) Option {
+	return optionFunc{func(c *Config) {
+		c.OnWriteError = f
+	}}
+}
+
+// WithErrorOutput sets the writer to report write errors.
+func WithErrorOutput(w io.Writer) Option {
+	return optionFunc{func(c *Config) {
+		c.ErrorOutput = w
+	}}
+}
+
+// WithWriteErrorInterval sets the minimum interval between reports of write errors.
+func WithWriteErrorInterval(d time.Duration) Option {
+	return optionFunc{func(c *Config) {
+		c.WriteErrorInterval = d
+	}}
+}
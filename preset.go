@@ -0,0 +1,126 @@
+package zlog
+
+import (
+	"log/slog"
+	"time"
+)
+
+// WithGCPFormat sets the format of Google Cloud Logging structured logs.
+// https://cloud.google.com/logging/docs/structured-logging
+//
+//	{"time":"2023-09-09T19:43:07.713+08:00","severity":"WARNING","logging.googleapis.com/sourceLocation":{"file":"app/main.go","line":"42","function":"main.main"},"message":"hello"}
+//
+// Levels are named by the LogSeverity, LevelTrace as "DEBUG", LevelNotice as "NOTICE",
+// slog.LevelWarn as "WARNING", LevelCritical as "CRITICAL", LevelPanic as "ALERT" and
+// LevelFatal as "EMERGENCY", the stack trace is written to "stack_trace" for Error Reporting.
+func WithGCPFormat() Option {
+	return optionFunc{func(c *Config) {
+		c.TimeKey = "time"
+		c.LevelKey = "severity"
+		c.MessageKey = "message"
+		c.SourceKey = "logging.googleapis.com/sourceLocation"
+		c.StacktraceKey = "stack_trace"
+		c.SourceObject = &SourceObject{
+			FileKey:      "file",
+			LineKey:      "line",
+			FunctionKey:  "function",
+			LineAsString: true,
+		}
+		c.LevelNames = LevelNames{
+			LevelTrace:      "DEBUG",
+			slog.LevelDebug: "DEBUG",
+			slog.LevelInfo:  "INFO",
+			LevelNotice:     "NOTICE",
+			slog.LevelWarn:  "WARNING",
+			slog.LevelError: "ERROR",
+			LevelCritical:   "CRITICAL",
+			LevelPanic:      "ALERT",
+			LevelFatal:      "EMERGENCY",
+		}
+	}}
+}
+
+// WithECSFormat sets the format of Elastic Common Schema.
+// https://www.elastic.co/guide/en/ecs/current/ecs-log.html
+//
+//	{"@timestamp":"2023-09-09T19:43:07.713+08:00","log.level":"warn","log.logger":"app","log.origin":{"file.name":"app/main.go","file.line":42,"function":"main.main"},"message":"hello"}
+//
+// Levels are named in lower case, the stack trace is written to "error.stack_trace".
+func WithECSFormat() Option {
+	return optionFunc{func(c *Config) {
+		c.TimeKey = "@timestamp"
+		c.LevelKey = "log.level"
+		c.MessageKey = "message"
+		c.LoggerKey = "log.logger"
+		c.SourceKey = "log.origin"
+		c.StacktraceKey = "error.stack_trace"
+		c.SourceObject = &SourceObject{
+			FileKey:     "file.name",
+			LineKey:     "file.line",
+			FunctionKey: "function",
+		}
+		c.LevelNames = LevelNames{
+			LevelTrace:      "trace",
+			slog.LevelDebug: "debug",
+			slog.LevelInfo:  "info",
+			LevelNotice:     "notice",
+			slog.LevelWarn:  "warn",
+			slog.LevelError: "error",
+			LevelCritical:   "critical",
+			LevelPanic:      "panic",
+			LevelFatal:      "fatal",
+		}
+	}}
+}
+
+// WithDatadogFormat sets the format of the Datadog standard attributes.
+// https://docs.datadoghq.com/logs/log_configuration/attributes_naming_convention/
+//
+//	{"timestamp":"2023-09-09T19:43:07.713+08:00","status":"WARN","logger.name":"app","caller":"app/main.go:42","message":"hello"}
+//
+// Levels are named by the status remapper, LevelPanic as "ALERT" and LevelFatal as
+// "EMERGENCY". The source is written to "caller", because "source" is reserved for the
+// integration name in Datadog, the stack trace is written to "error.stack".
+func WithDatadogFormat() Option {
+	return optionFunc{func(c *Config) {
+		c.TimeKey = "timestamp"
+		c.LevelKey = "status"
+		c.MessageKey = "message"
+		c.LoggerKey = "logger.name"
+		c.SourceKey = "caller"
+		c.StacktraceKey = "error.stack"
+		c.SourceObject = nil
+		c.LevelNames = LevelNames{
+			LevelTrace:      "TRACE",
+			slog.LevelDebug: "DEBUG",
+			slog.LevelInfo:  "INFO",
+			LevelNotice:     "NOTICE",
+			slog.LevelWarn:  "WARN",
+			slog.LevelError: "ERROR",
+			LevelCritical:   "CRITICAL",
+			LevelPanic:      "ALERT",
+			LevelFatal:      "EMERGENCY",
+		}
+	}}
+}
+
+// WithAWSFormat sets the format of the AWS Lambda JSON logs in CloudWatch.
+// https://docs.aws.amazon.com/lambda/latest/dg/monitoring-cloudwatchlogs.html
+//
+//	{"timestamp":"2023-09-09T11:43:07.713Z","level":"WARN","message":"hello"}
+//
+// The time is formatted in UTC, levels have the default names, e.g. "TRACE", "FATAL".
+func WithAWSFormat() Option {
+	return optionFunc{func(c *Config) {
+		c.TimeKey = "timestamp"
+		c.LevelKey = slog.LevelKey
+		c.MessageKey = "message"
+		c.SourceKey = slog.SourceKey
+		c.StacktraceKey = "stackTrace"
+		c.SourceObject = nil
+		c.TimeFormatter = func(buf []byte, t time.Time) []byte {
+			return t.UTC().AppendFormat(buf, RFC3339Milli)
+		}
+		c.LevelNames = nil
+	}}
+}
@@ -0,0 +1,145 @@
+package zlog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPresetFormats(t *testing.T) {
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	f, _ := runtime.CallersFrames(pcs[:]).Next()
+	file, line := shortFile(f.File), strconv.Itoa(f.Line)
+	now := time.Date(2023, 9, 9, 19, 43, 7, 713000000, time.FixedZone("", 8*3600))
+
+	tests := []struct {
+		name     string
+		option   Option
+		level    slog.Level
+		expected string
+	}{
+		{
+			name:     "gcp",
+			option:   WithGCPFormat(),
+			level:    slog.LevelWarn,
+			expected: `{"time":"2023-09-09T19:43:07.713+08:00","severity":"WARNING","logger":"app","logging.googleapis.com/sourceLocation":{"file":"` + file + `","line":"` + line + `","function":"` + f.Function + `"},"message":"hello","k":"v"}`,
+		}, {
+			name:     "ecs",
+			option:   WithECSFormat(),
+			level:    LevelTrace,
+			expected: `{"@timestamp":"2023-09-09T19:43:07.713+08:00","log.level":"trace","log.logger":"app","log.origin":{"file.name":"` + file + `","file.line":` + line + `,"function":"` + f.Function + `"},"message":"hello","k":"v"}`,
+		}, {
+			name:     "datadog",
+			option:   WithDatadogFormat(),
+			level:    slog.LevelError,
+			expected: `{"timestamp":"2023-09-09T19:43:07.713+08:00","status":"ERROR","logger.name":"app","caller":"` + file + `:` + line + `","message":"hello","k":"v"}`,
+		}, {
+			name:     "aws",
+			option:   WithAWSFormat(),
+			level:    slog.LevelInfo,
+			expected: `{"timestamp":"2023-09-09T11:43:07.713Z","level":"INFO","logger":"app","source":"` + file + `:` + line + `","message":"hello","k":"v"}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := NewJSONHandler(&Config{Writer: &buf}).
+				WithOptions(test.option, WithAddSource(true), WithLevel(LevelTrace)).
+				WithName("app")
+			r := slog.NewRecord(now, test.level, "hello", pcs[0])
+			r.AddAttrs(slog.String("k", "v"))
+			if err := h.Handle(context.Background(), r); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSuffix(buf.String(), "\n"); got != test.expected {
+				t.Errorf("got %s, want %s", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestPresetFormatOverride(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewJSONHandler(&Config{Writer: &buf}).
+		WithOptions(WithGCPFormat(), WithAddSource(true), WithSourceObject(nil), WithLevelNames(nil))
+	New(h).Warn("hello")
+	_, file, _, _ := runtime.Caller(0)
+	got := lineRegexp.ReplaceAllString(buf.String(), ".go:N")
+	if !strings.Contains(got, `"severity":"WARN","logging.googleapis.com/sourceLocation":"`+shortFile(file)+`:N","message":"hello"`) {
+		t.Errorf("unexpected output %s", got)
+	}
+}
+
+func TestSourceObjectWithReplaceAttr(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewJSONHandler(&Config{
+		HandlerOptions: slog.HandlerOptions{
+			AddSource: true,
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		},
+		Writer:       &buf,
+		CallerFormat: CallerPackage,
+		SourceObject: &SourceObject{FileKey: "file", LineKey: "line"},
+	})
+	New(h).Info("hello")
+	got := regexp.MustCompile(`"line":\d+`).ReplaceAllString(buf.String(), `"line":N`)
+	expected := `{"level":"INFO","source":{"file":"github.com/icefed/zlog/preset_test.go","line":N},"msg":"hello"}` + "\n"
+	if got != expected {
+		t.Errorf("got %s, want %s", got, expected)
+	}
+}
+
+func TestPresetLevelNames(t *testing.T) {
+	levels := []slog.Level{LevelTrace, slog.LevelDebug, slog.LevelInfo, LevelNotice, slog.LevelWarn, slog.LevelError, LevelCritical, LevelPanic, LevelFatal}
+	tests := []struct {
+		name     string
+		option   Option
+		expected string
+	}{
+		{
+			name:     "gcp",
+			option:   WithGCPFormat(),
+			expected: "DEBUG,DEBUG,INFO,NOTICE,WARNING,ERROR,CRITICAL,ALERT,EMERGENCY",
+		}, {
+			name:     "ecs",
+			option:   WithECSFormat(),
+			expected: "trace,debug,info,notice,warn,error,critical,panic,fatal",
+		}, {
+			name:     "datadog",
+			option:   WithDatadogFormat(),
+			expected: "TRACE,DEBUG,INFO,NOTICE,WARN,ERROR,CRITICAL,ALERT,EMERGENCY",
+		}, {
+			name:     "aws",
+			option:   WithAWSFormat(),
+			expected: "TRACE,DEBUG,INFO,NOTICE,WARN,ERROR,CRITICAL,PANIC,FATAL",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := NewJSONHandler(nil).WithOptions(test.option)
+			var names []string
+			for _, l := range levels {
+				names = append(names, h.c.LevelNames.name(l))
+			}
+			if got := strings.Join(names, ","); got != test.expected {
+				t.Errorf("got %s, want %s", got, test.expected)
+			}
+			// the shared names are parsed as the highest level
+			if l, err := h.c.LevelNames.Parse("debug"); err != nil || l != slog.LevelDebug {
+				t.Errorf("got %v %v, want %v", l, err, slog.LevelDebug)
+			}
+		})
+	}
+}
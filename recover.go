@@ -0,0 +1,150 @@
+package zlog
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/icefed/zlog/buffer"
+)
+
+// RecoverOption is the option for Recover and Go.
+type RecoverOption interface {
+	apply(*recoverConfig)
+}
+
+type recoverOptionFunc struct {
+	f func(*recoverConfig)
+}
+
+func (o recoverOptionFunc) apply(c *recoverConfig) {
+	o.f(c)
+}
+
+type recoverConfig struct {
+	level   slog.Level
+	repanic bool
+}
+
+// WithRecoverLevel sets the level to log the recovered panics, default is slog.LevelError.
+func WithRecoverLevel(level slog.Level) RecoverOption {
+	return recoverOptionFunc{func(c *recoverConfig) {
+		c.level = level
+	}}
+}
+
+// WithRepanic panics again with the recovered value after the panic is logged and
+// the logger is synced, so the program still crashes.
+func WithRepanic(repanic bool) RecoverOption {
+	return recoverOptionFunc{func(c *recoverConfig) {
+		c.repanic = repanic
+	}}
+}
+
+// Recover recovers the panic and logs it with the msg, the panic value as "panic",
+// the type of the value as "panicType", and the stack of the panicking goroutine
+// as "stack", which is formatted by the stacktrace options of the handler, e.g.
+// Config.StacktraceMaxDepth. The source of the log is where the panic happened.
+// Recover must be called directly by defer, if l is nil, the default logger is used.
+//
+//	func handle() {
+//		defer zlog.Recover(log, "handle panicked")
+//		...
+//	}
+func Recover(l *Logger, msg string, opts ...RecoverOption) {
+	// recover only works when it is called directly by the deferred function
+	if v := recover(); v != nil {
+		logPanic(l, msg, v, opts)
+	}
+}
+
+// Go runs fn in a new goroutine, the panic of fn is recovered and logged like Recover.
+func Go(l *Logger, fn func(), opts ...RecoverOption) {
+	go func() {
+		defer Recover(l, "goroutine panicked", opts...)
+		fn()
+	}()
+}
+
+func logPanic(l *Logger, msg string, v any, opts []RecoverOption) {
+	c := recoverConfig{
+		level: slog.LevelError,
+	}
+	for _, opt := range opts {
+		opt.apply(&c)
+	}
+	if l == nil {
+		l = defaultLogger
+	}
+
+	ctx := l.context(nil)
+	if l.Enabled(ctx, c.level) {
+		pc := panicPC()
+		attrs := []slog.Attr{
+			slog.Any("panic", v),
+			slog.String("panicType", fmt.Sprintf("%T", v)),
+		}
+		if pc != 0 {
+			attrs = append(attrs, panicStack(l.h, pc))
+		}
+		if !l.capturePC(c.level) {
+			pc = 0
+		}
+		r := slog.NewRecord(time.Now(), c.level, msg, pc)
+		r.AddAttrs(attrs...)
+		// write errors are reported by the handler, see Config.OnWriteError
+		_ = l.h.Handle(ctx, r)
+	}
+
+	if c.repanic {
+		_ = l.Sync()
+		panic(v)
+	}
+}
+
+// stacktraceConfiger is implemented by the zlog handlers, it returns the config
+// to format the stack traces, e.g. Config.StacktraceMaxDepth.
+type stacktraceConfiger interface {
+	stacktraceConfig() *Config
+}
+
+// panicStack returns the "stack" attribute of the panic, formatted by the stacktrace
+// config of the handler like its own stack traces, or with all frames as a string
+// if the handler is not a zlog handler, e.g. MultiHandler whose handlers may differ.
+// The frames are taken when the attribute is encoded, so h must handle the record
+// while the panicking goroutine is unwinding.
+func panicStack(h slog.Handler, pc uintptr) slog.Attr {
+	if sc, ok := h.(stacktraceConfiger); ok {
+		if c := sc.stacktraceConfig(); c != nil {
+			return slog.Any("stack", &stacktrace{pc: pc, c: c})
+		}
+	}
+	buf := buffer.New()
+	defer buf.Free()
+	formatStacktrace(buf, &stacktrace{pc: pc})
+	// copy the string, buf is reused after Free
+	return slog.String("stack", string(buf.Bytes()))
+}
+
+// panicPC returns the pc of the frame where the panic happened, it is the first
+// frame out of the runtime package after runtime.gopanic, frames like runtime.panicmem
+// are skipped. It returns 0 if the frame is not found.
+func panicPC() uintptr {
+	pcs := *newStacktracePCs()
+	defer pcs.Free()
+	n := runtime.Callers(1, pcs)
+
+	panicking := false
+	for _, pc := range pcs[:n] {
+		f, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		if panicking && !strings.HasPrefix(f.Function, "runtime.") {
+			return pc
+		}
+		if f.Function == "runtime.gopanic" {
+			panicking = true
+		}
+	}
+	return 0
+}
@@ -0,0 +1,200 @@
+package zlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recoverEntry struct {
+	Level     string
+	Msg       string
+	Source    string
+	Panic     any
+	PanicType string
+	Stack     string
+}
+
+//go:noinline
+func recoverPanicker(v any) {
+	panic(v)
+}
+
+//go:noinline
+func recoverNilDeref() {
+	var m *struct{ v int }
+	_ = m.v
+}
+
+func TestRecover(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := New(NewJSONHandler(&Config{
+		HandlerOptions: slog.HandlerOptions{
+			AddSource: true,
+		},
+		Writer:       buf,
+		CallerFormat: CallerShortFunc,
+	}))
+	parse := func() recoverEntry {
+		t.Helper()
+		var e recoverEntry
+		if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+			t.Fatalf("%v: %s", err, buf.String())
+		}
+		buf.Reset()
+		return e
+	}
+
+	func() {
+		defer Recover(l, "recovered")
+		recoverPanicker("boom")
+	}()
+	e := parse()
+	if e.Level != "ERROR" || e.Msg != "recovered" || e.Panic != "boom" || e.PanicType != "string" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if !strings.HasSuffix(e.Source, " zlog.recoverPanicker") {
+		t.Errorf("got source %s, want the panicking function", e.Source)
+	}
+	if !strings.HasPrefix(e.Stack, "github.com/icefed/zlog.recoverPanicker\n") || !strings.Contains(e.Stack, "github.com/icefed/zlog.TestRecover") {
+		t.Errorf("unexpected stack %s", e.Stack)
+	}
+
+	// runtime error
+	func() {
+		defer Recover(l, "recovered", WithRecoverLevel(LevelPanic))
+		recoverNilDeref()
+	}()
+	e = parse()
+	if e.Level != "PANIC" || e.PanicType != "runtime.Error" && !strings.HasPrefix(e.PanicType, "runtime.") || !strings.HasPrefix(e.Stack, "github.com/icefed/zlog.recoverNilDeref\n") {
+		t.Errorf("unexpected entry %+v", e)
+	}
+
+	// no panic
+	func() {
+		defer Recover(l, "recovered")
+	}()
+	if buf.Len() != 0 {
+		t.Errorf("unexpected log %s", buf.String())
+	}
+
+	// disabled level
+	func() {
+		defer Recover(l, "recovered", WithRecoverLevel(slog.LevelDebug))
+		recoverPanicker("boom")
+	}()
+	if buf.Len() != 0 {
+		t.Errorf("unexpected log %s", buf.String())
+	}
+
+	// repanic
+	err := errors.New("boom")
+	func() {
+		defer func() {
+			if r := recover(); r != err {
+				t.Errorf("got panic %v, want %v", r, err)
+			}
+		}()
+		defer Recover(l, "recovered", WithRepanic(true))
+		recoverPanicker(err)
+	}()
+	e = parse()
+	if e.Panic != "boom" || e.PanicType != "*errors.errorString" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+}
+
+func TestRecoverStacktraceConfig(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	c := &Config{
+		Writer:                 buf,
+		StacktraceMaxDepth:     2,
+		StacktraceSkipPrefixes: []string{"testing"},
+	}
+	recoverWith := func(l *Logger) {
+		defer Recover(l, "recovered")
+		recoverPanicker("boom")
+	}
+
+	// the frames are filtered by the handler config
+	recoverWith(New(NewJSONHandler(c)))
+	var e recoverEntry
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatalf("%v: %s", err, buf.String())
+	}
+	frames := strings.Split(e.Stack, "\n\t")
+	if len(frames) != 3 || !strings.HasPrefix(e.Stack, "github.com/icefed/zlog.recoverPanicker\n") {
+		t.Errorf("got stack %s, want 2 frames from recoverPanicker", e.Stack)
+	}
+	buf.Reset()
+
+	// the handler is wrapped by SamplingHandler
+	recoverWith(New(NewSamplingHandler(NewJSONHandler(c), nil)))
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatalf("%v: %s", err, buf.String())
+	}
+	if len(strings.Split(e.Stack, "\n\t")) != 3 {
+		t.Errorf("got stack %s, want 2 frames", e.Stack)
+	}
+	buf.Reset()
+
+	// structured frames
+	c.StacktraceFrames = true
+	recoverWith(New(NewJSONHandler(c)))
+	var entry struct {
+		Stack []struct {
+			Func string
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("%v: %s", err, buf.String())
+	}
+	if len(entry.Stack) != 2 || entry.Stack[0].Func != "github.com/icefed/zlog.recoverPanicker" {
+		t.Errorf("got stack %+v, want 2 frames from recoverPanicker", entry.Stack)
+	}
+	buf.Reset()
+
+	// testing frames are skipped
+	c.StacktraceFrames = false
+	c.StacktraceMaxDepth = 0
+	recoverWith(New(NewTextHandler(c)))
+	if out := buf.String(); !strings.Contains(out, "zlog.recoverPanicker") || strings.Contains(out, "testing.tRunner") {
+		t.Errorf("got %s, want the testing frames skipped", out)
+	}
+}
+
+// notifyWriter closes done after the first write.
+type notifyWriter struct {
+	w    io.Writer
+	done chan struct{}
+	once sync.Once
+}
+
+func (w *notifyWriter) Write(p []byte) (int, error) {
+	defer w.once.Do(func() { close(w.done) })
+	return w.w.Write(p)
+}
+
+func TestGo(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	done := make(chan struct{})
+	l := New(NewJSONHandler(&Config{Writer: &notifyWriter{w: buf, done: done}}))
+
+	Go(l, func() {
+		recoverPanicker("boom")
+	})
+	<-done
+
+	var e recoverEntry
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatalf("%v: %s", err, buf.String())
+	}
+	if e.Msg != "goroutine panicked" || e.Panic != "boom" || !strings.Contains(e.Stack, "github.com/icefed/zlog.TestGo.func1") {
+		t.Errorf("unexpected entry %+v", e)
+	}
+}
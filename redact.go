@@ -0,0 +1,406 @@
+package zlog
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// RedactMode is the way to mask the sensitive values.
+type RedactMode int
+
+const (
+	// RedactFull replaces the whole value with "[REDACTED]".
+	RedactFull RedactMode = iota
+	// RedactPartial masks the value with '*' except the last quarter of the characters,
+	// at most 4 characters are kept, e.g. "4111111111111111" is masked as "************1111".
+	RedactPartial
+	// RedactHash replaces the value with the prefix of its sha256 hash, e.g. "sha256:9f86d081884c7d65",
+	// so the same values can still be correlated.
+	RedactHash
+)
+
+const redactedText = "[REDACTED]"
+
+// mask returns the masked s in the mode.
+func (m RedactMode) mask(s string) string {
+	switch m {
+	case RedactPartial:
+		n := utf8.RuneCountInString(s)
+		keep := min(n/4, 4)
+		var b strings.Builder
+		b.Grow(len(s))
+		i := 0
+		for _, r := range s {
+			if i < n-keep {
+				b.WriteByte('*')
+			} else {
+				b.WriteRune(r)
+			}
+			i++
+		}
+		return b.String()
+	case RedactHash:
+		sum := sha256.Sum256([]byte(s))
+		return fmt.Sprintf("sha256:%x", sum[:8])
+	default:
+		return redactedText
+	}
+}
+
+// RedactRule is a rule to find and mask the sensitive values.
+//
+// If only Key is set, the whole values of the matched attributes are masked.
+// If only Value is set, the substrings matching Value in all the string values are masked.
+// If both are set, the substrings matching Value in the string values of the matched
+// attributes are masked.
+type RedactRule struct {
+	// Key is a glob pattern of path.Match to match the attribute key case-insensitively,
+	// e.g. "*password*". If it contains ".", it matches the key qualified by the
+	// names of its groups, e.g. "user.token" matches the key "token" in the group "user".
+	Key string
+	// Value is a regular expression to match the sensitive parts of string values.
+	Value *regexp.Regexp
+	// Mode is the way to mask the value, default is RedactFull.
+	Mode RedactMode
+}
+
+func (rule *RedactRule) matchKey(groups []string, key string) bool {
+	if rule.Key == "" {
+		return true
+	}
+	if strings.Contains(rule.Key, ".") {
+		key = strings.Join(append(slices.Clip(groups), key), ".")
+	}
+	matched, _ := path.Match(rule.Key, strings.ToLower(key))
+	return matched
+}
+
+// Redactor masks the sensitive values of attributes before they are encoded,
+// set it by Config.Redactor.
+//
+// Besides the rules, fields of struct values tagged with `zlog:"redact"` are masked
+// when the values are encoded by encoding/json, the mode can be set in the tag,
+// e.g. `zlog:"redact,partial"`, `zlog:"redact,hash"`. Tagged fields of nested structs
+// are masked too, but not the structs in slices or maps.
+type Redactor struct {
+	rules []RedactRule
+}
+
+// NewRedactor creates a Redactor with the rules, rules are applied in order,
+// and the first key only rule matched masks the whole value.
+func NewRedactor(rules ...RedactRule) (*Redactor, error) {
+	r := &Redactor{
+		rules: make([]RedactRule, len(rules)),
+	}
+	for i, rule := range rules {
+		if rule.Key == "" && rule.Value == nil {
+			return nil, errors.New("zlog: redact rule must have Key or Value")
+		}
+		rule.Key = strings.ToLower(rule.Key)
+		if _, err := path.Match(rule.Key, ""); err != nil {
+			return nil, fmt.Errorf("zlog: invalid redact rule key %q: %w", rule.Key, err)
+		}
+		r.rules[i] = rule
+	}
+	return r, nil
+}
+
+// redactAttr returns the attribute with sensitive values masked, and whether it is changed.
+func (r *Redactor) redactAttr(groups []string, a slog.Attr) (slog.Attr, bool) {
+	a.Value = a.Value.Resolve()
+	for i := range r.rules {
+		rule := &r.rules[i]
+		if rule.Value != nil || rule.Key == "" || !rule.matchKey(groups, a.Key) {
+			continue
+		}
+		if a.Value.Kind() == slog.KindGroup {
+			return slog.String(a.Key, redactedText), true
+		}
+		return slog.String(a.Key, rule.Mode.mask(a.Value.String())), true
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		if a.Key != "" {
+			groups = append(slices.Clip(groups), a.Key)
+		}
+		groupAttrs := a.Value.Group()
+		var newAttrs []slog.Attr
+		for i := range groupAttrs {
+			ga, changed := r.redactAttr(groups, groupAttrs[i])
+			if changed && newAttrs == nil {
+				newAttrs = slices.Clone(groupAttrs)
+			}
+			if newAttrs != nil {
+				newAttrs[i] = ga
+			}
+		}
+		if newAttrs == nil {
+			return a, false
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(newAttrs...)}, true
+	case slog.KindString:
+		s := a.Value.String()
+		changed := false
+		for i := range r.rules {
+			rule := &r.rules[i]
+			if rule.Value == nil || !rule.matchKey(groups, a.Key) {
+				continue
+			}
+			masked := rule.Value.ReplaceAllStringFunc(s, rule.Mode.mask)
+			if masked != s {
+				s, changed = masked, true
+			}
+		}
+		if changed {
+			return slog.String(a.Key, s), true
+		}
+	case slog.KindAny:
+		if v, ok := redactStructValue(a.Value.Any()); ok {
+			return slog.Any(a.Key, v), true
+		}
+	}
+	return a, false
+}
+
+// redactStruct describes how to copy a struct type to a type with tagged fields masked.
+type redactStruct struct {
+	typ    reflect.Type
+	fields []redactField
+}
+
+type redactField struct {
+	index  []int
+	mode   RedactMode
+	redact bool
+	// nested is not nil if the field is a struct or a pointer to struct with tagged fields.
+	nested *redactStruct
+	// dynamic means the field refers to a type being built, its value is copied at runtime.
+	dynamic bool
+}
+
+// redactStructs caches *redactStruct by reflect.Type, nil if the type has no tagged fields.
+var redactStructs sync.Map
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	errorType         = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// redactStructValue returns a copy of v with tagged fields masked, if v is a struct
+// or a pointer to struct that has tagged fields.
+func redactStructValue(v any) (any, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, false
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, false
+	}
+	// values encoded by their own methods are not changed
+	if implementsEncoder(reflect.TypeOf(v)) {
+		return nil, false
+	}
+	rs := getRedactStruct(rv.Type())
+	if rs == nil {
+		return nil, false
+	}
+	return rs.copy(rv).Interface(), true
+}
+
+func implementsEncoder(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) || t.Implements(errorType)
+}
+
+func getRedactStruct(t reflect.Type) *redactStruct {
+	if rs, ok := redactStructs.Load(t); ok {
+		return rs.(*redactStruct)
+	}
+	return getRedactStructVisiting(t, map[reflect.Type]bool{})
+}
+
+// getRedactStructVisiting returns the cached *redactStruct of t, or builds it,
+// types being built in visiting are not nested again to stop the recursion of
+// self-referential types.
+func getRedactStructVisiting(t reflect.Type, visiting map[reflect.Type]bool) *redactStruct {
+	if rs, ok := redactStructs.Load(t); ok {
+		return rs.(*redactStruct)
+	}
+	if visiting[t] {
+		return nil
+	}
+	visiting[t] = true
+	rs := buildRedactStruct(t, visiting)
+	delete(visiting, t)
+	if len(visiting) == 0 {
+		// only cache the complete results of the outermost type
+		redactStructs.Store(t, rs)
+	}
+	return rs
+}
+
+func buildRedactStruct(t reflect.Type, visiting map[reflect.Type]bool) *redactStruct {
+	var (
+		fields       []redactField
+		structFields []reflect.StructField
+		// fieldPos is the position of field names in fields, the shallower field wins
+		// if names are conflicted like encoding/json.
+		fieldPos = map[string]int{}
+		tagged   bool
+	)
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			fieldIndex := append(slices.Clip(index), i)
+			jsonTag := sf.Tag.Get("json")
+			if jsonTag == "-" {
+				continue
+			}
+			jsonName, _, _ := strings.Cut(jsonTag, ",")
+			// fields of embedded structs are flattened like encoding/json
+			if sf.Anonymous && jsonName == "" {
+				ft := sf.Type
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					walk(ft, fieldIndex)
+					continue
+				}
+			}
+			if !sf.IsExported() {
+				continue
+			}
+			if pos, ok := fieldPos[sf.Name]; ok {
+				if len(fields[pos].index) <= len(fieldIndex) {
+					continue
+				}
+				// replaced by the shallower field
+				fields = slices.Delete(fields, pos, pos+1)
+				structFields = slices.Delete(structFields, pos, pos+1)
+				for name, p := range fieldPos {
+					if p > pos {
+						fieldPos[name] = p - 1
+					}
+				}
+			}
+			fieldPos[sf.Name] = len(fields)
+
+			field := redactField{index: fieldIndex}
+			newField := reflect.StructField{
+				Name: sf.Name,
+				Type: sf.Type,
+				Tag:  sf.Tag,
+			}
+			if name, opts, _ := strings.Cut(sf.Tag.Get("zlog"), ","); name == "redact" {
+				field.redact = true
+				switch opts {
+				case "partial":
+					field.mode = RedactPartial
+				case "hash":
+					field.mode = RedactHash
+				}
+				newField.Type = reflect.TypeOf("")
+				// drop the options of json tag such as ",string", except omitempty
+				tag := fmt.Sprintf(`json:%q`, jsonName)
+				if strings.Contains(jsonTag, ",omitempty") {
+					tag = fmt.Sprintf(`json:"%s,omitempty"`, jsonName)
+				}
+				newField.Tag = reflect.StructTag(tag)
+				tagged = true
+			} else if ft := sf.Type; !implementsEncoder(ft) {
+				isPtr := ft.Kind() == reflect.Pointer
+				if isPtr {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct && !implementsEncoder(reflect.PointerTo(ft)) && visiting[ft] {
+					// self-referential types can not be built by reflect.StructOf,
+					// so the field is typed as any
+					field.dynamic = true
+					newField.Type = reflect.TypeOf((*any)(nil)).Elem()
+				} else if ft.Kind() == reflect.Struct && !implementsEncoder(reflect.PointerTo(ft)) {
+					if nested := getRedactStructVisiting(ft, visiting); nested != nil {
+						field.nested = nested
+						newField.Type = nested.typ
+						if isPtr {
+							newField.Type = reflect.PointerTo(nested.typ)
+						}
+						tagged = true
+					}
+				}
+			}
+			fields = append(fields, field)
+			structFields = append(structFields, newField)
+		}
+	}
+	walk(t, nil)
+	if !tagged {
+		return nil
+	}
+	return &redactStruct{
+		typ:    reflect.StructOf(structFields),
+		fields: fields,
+	}
+}
+
+// copy returns a copy of the struct value v in the type with tagged fields masked.
+func (rs *redactStruct) copy(v reflect.Value) reflect.Value {
+	nv := reflect.New(rs.typ).Elem()
+	for i, field := range rs.fields {
+		fv, err := v.FieldByIndexErr(field.index)
+		if err != nil {
+			// nil embedded pointer
+			continue
+		}
+		switch {
+		case field.redact:
+			// zero values are kept empty, so omitempty works
+			if fv.IsZero() {
+				continue
+			}
+			if fv.Kind() == reflect.Pointer {
+				fv = fv.Elem()
+			}
+			nv.Field(i).SetString(field.mode.mask(fmt.Sprint(fv.Interface())))
+		case field.nested != nil:
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				p := reflect.New(field.nested.typ)
+				p.Elem().Set(field.nested.copy(fv.Elem()))
+				nv.Field(i).Set(p)
+			} else {
+				nv.Field(i).Set(field.nested.copy(fv))
+			}
+		case field.dynamic:
+			if fv.Kind() == reflect.Pointer && fv.IsNil() {
+				continue
+			}
+			if rv, ok := redactStructValue(fv.Interface()); ok {
+				nv.Field(i).Set(reflect.ValueOf(rv))
+			} else {
+				nv.Field(i).Set(fv)
+			}
+		default:
+			nv.Field(i).Set(fv)
+		}
+	}
+	return nv
+}
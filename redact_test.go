@@ -0,0 +1,169 @@
+package zlog
+
+import (
+	"bytes"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedactMode(t *testing.T) {
+	tests := []struct {
+		mode     RedactMode
+		s        string
+		expected string
+	}{
+		{RedactFull, "secret", "[REDACTED]"},
+		{RedactPartial, "4111111111111111", "************1111"},
+		{RedactPartial, "password", "******rd"},
+		{RedactPartial, "abc", "***"},
+		{RedactPartial, "密码密码", "***码"},
+		{RedactHash, "test", "sha256:9f86d081884c7d65"},
+	}
+	for _, test := range tests {
+		if got := test.mode.mask(test.s); got != test.expected {
+			t.Errorf("mask(%q) = %q, want %q", test.s, got, test.expected)
+		}
+	}
+}
+
+func TestNewRedactor(t *testing.T) {
+	if _, err := NewRedactor(RedactRule{}); err == nil {
+		t.Error("want error for empty rule")
+	}
+	if _, err := NewRedactor(RedactRule{Key: "[a-"}); err == nil {
+		t.Error("want error for bad pattern")
+	}
+}
+
+type redactAddress struct {
+	City   string
+	Street string `json:"street" zlog:"redact,hash"`
+}
+
+type redactEmbedded struct {
+	Token string `json:"token,omitempty" zlog:"redact"`
+}
+
+type redactUser struct {
+	redactEmbedded
+	Name     string         `json:"name"`
+	Password string         `json:"password" zlog:"redact"`
+	Card     int64          `json:"card,string" zlog:"redact,partial"`
+	Address  *redactAddress `json:"address,omitempty"`
+	Created  time.Time      `json:"-"`
+	age      int
+}
+
+type redactNode struct {
+	Secret string `json:"secret" zlog:"redact"`
+	Next   *redactNode
+}
+
+func TestRedactor(t *testing.T) {
+	r, err := NewRedactor(
+		RedactRule{Key: "*password*"},
+		RedactRule{Key: "auth.token", Mode: RedactHash},
+		RedactRule{Key: "card", Mode: RedactPartial},
+		RedactRule{Value: regexp.MustCompile(`[\w.]+@[\w.]+`)},
+		RedactRule{Key: "phone", Value: regexp.MustCompile(`\d{4}$`), Mode: RedactPartial},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.NewBuffer(nil)
+	h := NewJSONHandler(&Config{
+		HandlerOptions: slog.HandlerOptions{
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		},
+		Writer:   buf,
+		Redactor: r,
+	})
+
+	tests := []struct {
+		name     string
+		h        slog.Handler
+		attrs    []any
+		expected string
+	}{
+		{
+			name:     "key",
+			h:        h,
+			attrs:    []any{"password", "123456", "DB_PASSWORD", 123456, "token", "abc"},
+			expected: `{"level":"INFO","msg":"test","password":"[REDACTED]","DB_PASSWORD":"[REDACTED]","token":"abc"}`,
+		}, {
+			name:     "group path",
+			h:        h.WithGroup("auth"),
+			attrs:    []any{"token", "test", slog.Group("user", "token", "abc")},
+			expected: `{"level":"INFO","msg":"test","auth":{"token":"sha256:9f86d081884c7d65","user":{"token":"abc"}}}`,
+		}, {
+			name:     "nested group path",
+			h:        h,
+			attrs:    []any{slog.Group("auth", "token", "test", slog.Group("", "password", "abc"))},
+			expected: `{"level":"INFO","msg":"test","auth":{"token":"sha256:9f86d081884c7d65","password":"[REDACTED]"}}`,
+		}, {
+			name:     "group key",
+			h:        h,
+			attrs:    []any{slog.Group("password", "old", "abc", "new", "def")},
+			expected: `{"level":"INFO","msg":"test","password":"[REDACTED]"}`,
+		}, {
+			name:     "with attrs",
+			h:        h.WithAttrs([]slog.Attr{slog.Int64("card", 4111111111111111)}),
+			attrs:    nil,
+			expected: `{"level":"INFO","msg":"test","card":"************1111"}`,
+		}, {
+			name:     "value",
+			h:        h,
+			attrs:    []any{"to", "send to john@example.com and jane@example.com", "phone", "13800001234", "number", "13800001234"},
+			expected: `{"level":"INFO","msg":"test","to":"send to [REDACTED] and [REDACTED]","phone":"1380000***4","number":"13800001234"}`,
+		}, {
+			name: "struct tag",
+			h:    h,
+			attrs: []any{"user", redactUser{
+				redactEmbedded: redactEmbedded{Token: "abc"},
+				Name:           "john",
+				Password:       "123456",
+				Card:           4111111111111111,
+				Address:        &redactAddress{City: "Hangzhou", Street: "test"},
+				age:            18,
+			}},
+			expected: `{"level":"INFO","msg":"test","user":{"token":"[REDACTED]","name":"john","password":"[REDACTED]","card":"************1111","address":{"City":"Hangzhou","street":"sha256:9f86d081884c7d65"}}}`,
+		}, {
+			name:     "struct pointer",
+			h:        h,
+			attrs:    []any{"user", &redactUser{Name: "john", Password: "123456"}},
+			expected: `{"level":"INFO","msg":"test","user":{"name":"john","password":"[REDACTED]","card":""}}`,
+		}, {
+			name:     "self-referential struct",
+			h:        h,
+			attrs:    []any{"node", redactNode{Secret: "a", Next: &redactNode{Secret: "b"}}},
+			expected: `{"level":"INFO","msg":"test","node":{"secret":"[REDACTED]","Next":{"secret":"[REDACTED]","Next":null}}}`,
+		}, {
+			name:     "zero values",
+			h:        h,
+			attrs:    []any{"address", redactAddress{City: "Hangzhou"}, "created", time.Time{}},
+			expected: `{"level":"INFO","msg":"test","address":{"City":"Hangzhou","street":""},"created":"0001-01-01T00:00:00Z"}`,
+		}, {
+			name:     "text",
+			h:        NewTextHandler(h.c),
+			attrs:    []any{"password", "123456", "to", "john@example.com"},
+			expected: `level=INFO msg=test password=[REDACTED] to=[REDACTED]`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf.Reset()
+			slog.New(test.h).Info("test", test.attrs...)
+			if got := strings.TrimSuffix(buf.String(), "\n"); got != test.expected {
+				t.Errorf("got %s, want %s", got, test.expected)
+			}
+		})
+	}
+}
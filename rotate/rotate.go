@@ -0,0 +1,478 @@
+/*
+Package rotate implements an io.Writer that writes to a file and rotates it by size
+or time, it can be used as the zlog.Config Writer.
+
+	w, err := rotate.New(&rotate.Config{
+		Filename:   "/var/log/app/app.log",
+		MaxSize:    100 << 20,
+		Interval:   rotate.Daily,
+		MaxBackups: 7,
+		Compress:   true,
+	})
+	if err != nil {
+		// ...
+	}
+	defer w.Close()
+
+	h := zlog.NewJSONHandler(&zlog.Config{Writer: w})
+
+Rotated files are named by inserting the rotation time between the file name and
+the extension, e.g. app-2006-01-02T15-04-05.000.log, and app-2006-01-02T15-04-05.000.log.gz
+if compressed.
+*/
+package rotate
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Interval is the time interval for rotation.
+type Interval int
+
+const (
+	// Never disables time based rotation.
+	Never Interval = iota
+	// Hourly rotates at the beginning of every hour.
+	Hourly
+	// Daily rotates at midnight.
+	Daily
+)
+
+const (
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	compressSuffix   = ".gz"
+)
+
+// Config the configuration for the Writer.
+type Config struct {
+	// Filename is the file to write logs to, backups are kept in the same directory.
+	// If empty, <processname>.log in os.TempDir() is used.
+	Filename string
+
+	// MaxSize is the maximum size in bytes of the file before it gets rotated.
+	// Zero disables size based rotation.
+	MaxSize int64
+
+	// Interval rotates the file every hour or day. Default is Never.
+	Interval Interval
+
+	// MaxBackups is the maximum number of rotated files to keep.
+	// Zero means keep all of them, unless they are deleted by MaxAge.
+	MaxBackups int
+
+	// MaxAge is the maximum duration to keep rotated files, based on the time
+	// encoded in their name. Zero means not to remove files by age.
+	MaxAge time.Duration
+
+	// Compress compresses rotated files with gzip.
+	Compress bool
+
+	// LocalTime uses the local time for backup names and interval boundaries,
+	// otherwise UTC is used.
+	LocalTime bool
+
+	// ReopenOnSIGHUP reopens the file when the process receives SIGHUP, so that
+	// the file can be rotated by external tools like logrotate.
+	ReopenOnSIGHUP bool
+}
+
+// Writer is an io.WriteCloser that writes to the configured file and rotates it.
+// Writer is safe for concurrent use.
+type Writer struct {
+	c Config
+
+	mu sync.Mutex
+	// file is nil after a failed rotation or reopen, it is opened again by the next write.
+	file         *os.File
+	size         int64
+	nextRotation time.Time
+	closed       bool
+
+	millCh   chan struct{}
+	millDone chan struct{}
+	sigCh    chan os.Signal
+
+	closeOnce sync.Once
+
+	// now is used to get the current time, replaced in tests.
+	now func() time.Time
+}
+
+// New creates a Writer and opens the file. If config is nil, a default
+// configuration is used.
+func New(config *Config) (*Writer, error) {
+	w := &Writer{
+		millCh:   make(chan struct{}, 1),
+		millDone: make(chan struct{}),
+		now:      time.Now,
+	}
+	if config != nil {
+		w.c = *config
+	}
+	if w.c.Filename == "" {
+		w.c.Filename = filepath.Join(os.TempDir(), filepath.Base(os.Args[0])+".log")
+	}
+
+	if err := w.openExistingOrNew(); err != nil {
+		return nil, err
+	}
+	go w.millRun()
+
+	if w.c.ReopenOnSIGHUP {
+		w.sigCh = make(chan os.Signal, 1)
+		signal.Notify(w.sigCh, syscall.SIGHUP)
+		go w.handleSignal(w.sigCh)
+	}
+	return w, nil
+}
+
+// Write writes p to the file, the file is rotated first if the write would exceed
+// MaxSize or the rotation interval is reached.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.file == nil {
+		if err := w.openExistingOrNew(); err != nil {
+			return 0, err
+		}
+	}
+	if w.needRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Sync commits the current contents of the file to stable storage.
+func (w *Writer) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return os.ErrClosed
+	}
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// Rotate closes the current file, renames it to a backup, and opens a new file.
+func (w *Writer) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return os.ErrClosed
+	}
+	return w.rotate()
+}
+
+// Reopen closes and reopens the file, it should be called after the file
+// has been moved by an external tool.
+func (w *Writer) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return os.ErrClosed
+	}
+	if w.file != nil {
+		err := w.file.Close()
+		w.file = nil
+		if err != nil {
+			return err
+		}
+	}
+	return w.openExistingOrNew()
+}
+
+// Close closes the file and stops the background goroutines.
+func (w *Writer) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		if w.sigCh != nil {
+			signal.Stop(w.sigCh)
+			close(w.sigCh)
+		}
+
+		w.mu.Lock()
+		w.closed = true
+		if w.file != nil {
+			err = w.file.Close()
+			w.file = nil
+		}
+		w.mu.Unlock()
+
+		close(w.millCh)
+		<-w.millDone
+	})
+	return err
+}
+
+func (w *Writer) handleSignal(ch chan os.Signal) {
+	for range ch {
+		_ = w.Reopen()
+	}
+}
+
+func (w *Writer) needRotate(writeLen int64) bool {
+	if w.c.MaxSize > 0 && w.size > 0 && w.size+writeLen > w.c.MaxSize {
+		return true
+	}
+	if w.c.Interval != Never && !w.currentTime().Before(w.nextRotation) {
+		return true
+	}
+	return false
+}
+
+// rotate renames the current file to a backup and opens a new file,
+// must be called with the lock held. If it fails, the file is left nil and
+// opened again by the next write.
+func (w *Writer) rotate() error {
+	if w.file != nil {
+		err := w.file.Close()
+		w.file = nil
+		if err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(w.c.Filename, w.backupName(w.currentTime())); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := w.openNew(); err != nil {
+		return err
+	}
+	w.mill()
+	return nil
+}
+
+func (w *Writer) openExistingOrNew() error {
+	info, err := os.Stat(w.c.Filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return w.openNew()
+	}
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(w.c.Filename, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	// the file may be written in a previous interval, compute the next
+	// rotation time from its modification time.
+	w.nextRotation = w.nextRotationTime(info.ModTime())
+	return nil
+}
+
+func (w *Writer) openNew() error {
+	if err := os.MkdirAll(filepath.Dir(w.c.Filename), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(w.c.Filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	w.file = file
+	w.size = 0
+	w.nextRotation = w.nextRotationTime(w.currentTime())
+	return nil
+}
+
+func (w *Writer) currentTime() time.Time {
+	if w.c.LocalTime {
+		return w.now().Local()
+	}
+	return w.now().UTC()
+}
+
+// nextRotationTime returns the start of the interval after t.
+func (w *Writer) nextRotationTime(t time.Time) time.Time {
+	if w.c.LocalTime {
+		t = t.Local()
+	} else {
+		t = t.UTC()
+	}
+	switch w.c.Interval {
+	case Hourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+	case Daily:
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+func (w *Writer) prefixAndExt() (string, string) {
+	base := filepath.Base(w.c.Filename)
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + "-", ext
+}
+
+func (w *Writer) backupName(t time.Time) string {
+	prefix, ext := w.prefixAndExt()
+	return filepath.Join(filepath.Dir(w.c.Filename), prefix+t.Format(backupTimeFormat)+ext)
+}
+
+// mill triggers removing and compressing old files in the background.
+func (w *Writer) mill() {
+	select {
+	case w.millCh <- struct{}{}:
+	default:
+	}
+}
+
+func (w *Writer) millRun() {
+	defer close(w.millDone)
+	for range w.millCh {
+		_ = w.millRunOnce()
+	}
+}
+
+type backupFile struct {
+	name      string
+	timestamp time.Time
+}
+
+// millRunOnce removes backups exceeding MaxBackups or MaxAge, and compresses the rest if needed.
+func (w *Writer) millRunOnce() error {
+	if w.c.MaxBackups == 0 && w.c.MaxAge == 0 && !w.c.Compress {
+		return nil
+	}
+	backups, err := w.backupFiles()
+	if err != nil {
+		return err
+	}
+
+	var remove []backupFile
+	if w.c.MaxBackups > 0 && len(backups) > w.c.MaxBackups {
+		remove = append(remove, backups[w.c.MaxBackups:]...)
+		backups = backups[:w.c.MaxBackups]
+	}
+	if w.c.MaxAge > 0 {
+		cutoff := w.currentTime().Add(-w.c.MaxAge)
+		var kept []backupFile
+		for _, b := range backups {
+			if b.timestamp.Before(cutoff) {
+				remove = append(remove, b)
+			} else {
+				kept = append(kept, b)
+			}
+		}
+		backups = kept
+	}
+
+	var errs []error
+	for _, b := range remove {
+		if err := os.Remove(b.name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	if w.c.Compress {
+		for _, b := range backups {
+			if strings.HasSuffix(b.name, compressSuffix) {
+				continue
+			}
+			if err := compressFile(b.name, b.name+compressSuffix); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// backupFiles returns the backup files sorted by time, newest first.
+func (w *Writer) backupFiles() ([]backupFile, error) {
+	dir := filepath.Dir(w.c.Filename)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	prefix, ext := w.prefixAndExt()
+
+	var backups []backupFile
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimPrefix(name, prefix)
+		ts = strings.TrimSuffix(ts, compressSuffix)
+		if !strings.HasSuffix(ts, ext) {
+			continue
+		}
+		ts = strings.TrimSuffix(ts, ext)
+		loc := time.UTC
+		if w.c.LocalTime {
+			loc = time.Local
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, ts, loc)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{
+			name:      filepath.Join(dir, name),
+			timestamp: t,
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].timestamp.After(backups[j].timestamp)
+	})
+	return backups, nil
+}
+
+// compressFile compresses src to dst with gzip, and removes src.
+func compressFile(src, dst string) (err error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gzf, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			gzf.Close()
+			os.Remove(dst)
+			err = fmt.Errorf("compress %s: %w", src, err)
+		}
+	}()
+
+	gz := gzip.NewWriter(gzf)
+	if _, err = io.Copy(gz, f); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if err = gzf.Close(); err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(src)
+}
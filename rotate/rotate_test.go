@@ -0,0 +1,238 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func newTestWriter(t *testing.T, c *Config, clock *fakeClock) *Writer {
+	t.Helper()
+	w, err := New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.now = clock.Now
+	w.nextRotation = w.nextRotationTime(clock.Now())
+	return w
+}
+
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestWriterRotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2023, 8, 16, 1, 2, 3, 0, time.UTC)}
+	w := newTestWriter(t, &Config{
+		Filename:   filepath.Join(dir, "app.log"),
+		MaxSize:    10,
+		MaxBackups: 2,
+	}, clock)
+
+	for _, s := range []string{"line1\n", "line2\n", "line3\n", "line4\n"} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+		clock.Add(time.Second)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"app-2023-08-16T01-02-05.000.log",
+		"app-2023-08-16T01-02-06.000.log",
+		"app.log",
+	}
+	got := listFiles(t, dir)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := readFile(t, filepath.Join(dir, "app.log")); got != "line4\n" {
+		t.Errorf("got %q, want %q", got, "line4\n")
+	}
+	if got := readFile(t, filepath.Join(dir, want[1])); got != "line3\n" {
+		t.Errorf("got %q, want %q", got, "line3\n")
+	}
+
+	if _, err := w.Write([]byte("closed\n")); err == nil {
+		t.Error("write after close, want error")
+	}
+}
+
+func TestWriterRotateByTime(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2023, 8, 16, 23, 59, 0, 0, time.UTC)}
+	w := newTestWriter(t, &Config{
+		Filename: filepath.Join(dir, "app.log"),
+		Interval: Daily,
+	}, clock)
+
+	w.Write([]byte("day1\n"))
+	clock.Add(time.Minute)
+	w.Write([]byte("day2\n"))
+	clock.Add(time.Hour)
+	w.Write([]byte("day2\n"))
+	w.Close()
+
+	want := []string{
+		"app-2023-08-17T00-00-00.000.log",
+		"app.log",
+	}
+	got := listFiles(t, dir)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := readFile(t, filepath.Join(dir, "app.log")); got != "day2\nday2\n" {
+		t.Errorf("got %q, want %q", got, "day2\nday2\n")
+	}
+
+	tests := []struct {
+		interval Interval
+		t        time.Time
+		want     time.Time
+	}{
+		{Hourly, time.Date(2023, 8, 16, 1, 2, 3, 0, time.UTC), time.Date(2023, 8, 16, 2, 0, 0, 0, time.UTC)},
+		{Daily, time.Date(2023, 8, 31, 1, 2, 3, 0, time.UTC), time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)},
+		{Never, time.Date(2023, 8, 16, 1, 2, 3, 0, time.UTC), time.Time{}},
+	}
+	for _, test := range tests {
+		w := &Writer{c: Config{Interval: test.interval}}
+		if got := w.nextRotationTime(test.t); !got.Equal(test.want) {
+			t.Errorf("got %v, want %v", got, test.want)
+		}
+	}
+}
+
+func TestWriterMaxAgeAndCompress(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2023, 8, 16, 1, 2, 3, 0, time.UTC)}
+	// an expired backup
+	old := filepath.Join(dir, "app-2023-08-01T00-00-00.000.log")
+	if err := os.WriteFile(old, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w := newTestWriter(t, &Config{
+		Filename: filepath.Join(dir, "app.log"),
+		MaxAge:   24 * time.Hour,
+		Compress: true,
+	}, clock)
+
+	w.Write([]byte("line1\n"))
+	if err := w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	want := []string{
+		"app-2023-08-16T01-02-03.000.log.gz",
+		"app.log",
+	}
+	got := listFiles(t, dir)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	f, err := os.Open(filepath.Join(dir, want[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "line1\n" {
+		t.Errorf("got %q, want %q", data, "line1\n")
+	}
+}
+
+func TestWriterRecoverFromFailedRotation(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	filename := filepath.Join(dir, "app.log")
+	w, err := New(&Config{Filename: filename})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// replace the directory with a file, so the new file can not be created
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Rotate(); err == nil {
+		t.Fatal("want rotate error")
+	}
+	if _, err := w.Write([]byte("lost\n")); err == nil || errors.Is(err, os.ErrClosed) {
+		t.Fatalf("got %v, want the open error", err)
+	}
+
+	// the next write opens the file again after the error is gone
+	if err := os.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("recovered\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filename); got != "recovered\n" {
+		t.Errorf("got %q, want %q", got, "recovered\n")
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("closed\n")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("got %v, want %v", err, os.ErrClosed)
+	}
+}
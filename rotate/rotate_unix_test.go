@@ -0,0 +1,54 @@
+//go:build !windows
+
+package rotate
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWriterReopen(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	w, err := New(&Config{
+		Filename:       name,
+		ReopenOnSIGHUP: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	w.Write([]byte("line1\n"))
+	// rotated by an external tool
+	if err := os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(name); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("file is not reopened")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	w.Write([]byte("line2\n"))
+	if err := w.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readFile(t, name+".1"); got != "line1\n" {
+		t.Errorf("got %q, want %q", got, "line1\n")
+	}
+	if got := readFile(t, name); got != "line2\n" {
+		t.Errorf("got %q, want %q", got, "line2\n")
+	}
+}
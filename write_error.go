@@ -0,0 +1,59 @@
+package zlog
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// defaultWriteErrorInterval is the default minimum interval between reports of write errors.
+const defaultWriteErrorInterval = time.Second
+
+// writeErrors counts the write errors of a handler and reports them with rate limiting,
+// it is shared by the handler and all handlers derived from it.
+type writeErrors struct {
+	count      atomic.Uint64
+	suppressed atomic.Uint64
+	// lastReport is the unix nano time of the last report.
+	lastReport atomic.Int64
+}
+
+// add counts the error and reports it by Config.OnWriteError and Config.ErrorOutput,
+// errors occurred within Config.WriteErrorInterval since the last report are
+// suppressed, and the number of them is reported with the next error.
+func (we *writeErrors) add(c *Config, err error) {
+	we.count.Add(1)
+	if c.OnWriteError == nil && c.ErrorOutput == nil {
+		return
+	}
+
+	now := time.Now()
+	interval := c.WriteErrorInterval
+	if interval == 0 {
+		interval = defaultWriteErrorInterval
+	}
+	if interval > 0 {
+		last := we.lastReport.Load()
+		if last != 0 && now.UnixNano()-last < interval.Nanoseconds() {
+			we.suppressed.Add(1)
+			return
+		}
+		if !we.lastReport.CompareAndSwap(last, now.UnixNano()) {
+			// reported by another goroutine
+			we.suppressed.Add(1)
+			return
+		}
+	}
+
+	suppressed := we.suppressed.Swap(0)
+	if c.OnWriteError != nil {
+		c.OnWriteError(err, suppressed)
+	}
+	if c.ErrorOutput != nil {
+		msg := fmt.Sprintf("%s zlog: failed to write log: %v", now.Format(RFC3339Milli), err)
+		if suppressed > 0 {
+			msg += fmt.Sprintf(" (%d errors suppressed)", suppressed)
+		}
+		fmt.Fprintln(c.ErrorOutput, msg)
+	}
+}
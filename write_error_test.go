@@ -0,0 +1,117 @@
+package zlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+type shortWriter struct{}
+
+func (shortWriter) Write(p []byte) (int, error) {
+	return len(p) / 2, nil
+}
+
+func TestWriteErrors(t *testing.T) {
+	writeErr := errors.New("no space left on device")
+	var (
+		errs       []error
+		suppressed uint64
+		output     bytes.Buffer
+	)
+	h := NewJSONHandler(&Config{
+		Writer: &errorWriter{err: writeErr},
+		OnWriteError: func(err error, n uint64) {
+			errs = append(errs, err)
+			suppressed += n
+		},
+		ErrorOutput:        &output,
+		WriteErrorInterval: time.Hour,
+	})
+	log := New(h.WithGroup("g"))
+	for i := 0; i < 5; i++ {
+		log.Info("test")
+	}
+	if h.WriteErrors() != 5 {
+		t.Errorf("got %d write errors, want 5", h.WriteErrors())
+	}
+	if len(errs) != 1 || errs[0] != writeErr {
+		t.Errorf("got errors %v, want one reported by rate limit", errs)
+	}
+	if !strings.HasSuffix(output.String(), " zlog: failed to write log: no space left on device\n") {
+		t.Errorf("got error output %q", output.String())
+	}
+
+	// report the suppressed errors with the next error
+	h.writeErrs.lastReport.Store(time.Now().Add(-time.Hour).UnixNano())
+	log.Info("test")
+	if len(errs) != 2 || suppressed != 4 {
+		t.Errorf("got %d errors reported, %d suppressed, want 2, 4", len(errs), suppressed)
+	}
+	if !strings.HasSuffix(output.String(), " zlog: failed to write log: no space left on device (4 errors suppressed)\n") {
+		t.Errorf("got error output %q", output.String())
+	}
+
+	// no rate limit
+	errs = nil
+	log = log.WithOptions(WithWriteErrorInterval(-1), WithErrorOutput(nil))
+	for i := 0; i < 3; i++ {
+		log.Info("test")
+	}
+	if len(errs) != 3 || h.WriteErrors() != 9 {
+		t.Errorf("got %d errors reported, %d counted, want 3, 9", len(errs), h.WriteErrors())
+	}
+
+	// short write
+	th := NewTextHandler(&Config{Writer: shortWriter{}})
+	if err := th.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "test", 0)); err != io.ErrShortWrite {
+		t.Errorf("got err %v, want %v", err, io.ErrShortWrite)
+	}
+	if th.WriteErrors() != 1 {
+		t.Errorf("got %d write errors, want 1", th.WriteErrors())
+	}
+}
+
+// failOnceWriter fails the first write.
+type failOnceWriter struct {
+	bytes.Buffer
+	failed bool
+}
+
+func (w *failOnceWriter) Write(p []byte) (int, error) {
+	if !w.failed {
+		w.failed = true
+		return 0, errors.New("write failed")
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestWriteErrorsLogInCallback(t *testing.T) {
+	w := &failOnceWriter{}
+	var log *Logger
+	log = New(NewJSONHandler(&Config{
+		Writer: w,
+		OnWriteError: func(err error, _ uint64) {
+			log.Warn("failed to write log", "error", err)
+		},
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		log.Info("test")
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlock when OnWriteError logs by the same handler")
+	}
+	if !strings.Contains(w.String(), `"msg":"failed to write log","error":"write failed"`) {
+		t.Errorf("got %q", w.String())
+	}
+}
@@ -0,0 +1,229 @@
+package zlog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/icefed/zlog/buffer"
+)
+
+// OverflowPolicy defines what AsyncWriter does when its queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the write until the queue has room.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest discards the record being written.
+	OverflowDropNewest
+	// OverflowDropOldest discards the oldest queued record to make room.
+	OverflowDropOldest
+)
+
+// ErrAsyncWriterClosed is returned by AsyncWriter after it has been closed.
+var ErrAsyncWriterClosed = errors.New("zlog: async writer closed")
+
+// AsyncWriterConfig the configuration for the AsyncWriter.
+type AsyncWriterConfig struct {
+	// QueueSize is the maximum number of records waiting to be written. Default is 1024.
+	QueueSize int
+	// Overflow is the policy used when the queue is full. Default is OverflowBlock.
+	Overflow OverflowPolicy
+	// OnWriteError is called in the background goroutine when writing a record to
+	// the underlying writer fails, the failed records are also counted by WriteErrors.
+	OnWriteError func(err error)
+}
+
+const defaultAsyncQueueSize = 1024
+
+// AsyncWriter is an io.Writer that copies every write into a bounded queue,
+// and writes it to the underlying writer in a background goroutine.
+//
+// Each Write is expected to be a whole record, as JSONHandler does. AsyncWriter
+// is safe for concurrent use, so the handler can be configured with
+// ConcurrentSafeWriter. Call Close to flush the queue and stop the goroutine.
+type AsyncWriter struct {
+	w            io.Writer
+	overflow     OverflowPolicy
+	onWriteError func(err error)
+
+	queue   chan *buffer.Buffer
+	flushCh chan chan struct{}
+	// closing is closed when Close is called, to wake up the blocked writes.
+	closing chan struct{}
+	done    chan struct{}
+	exited  chan struct{}
+
+	// mu is held for reading by Write around the enqueue, and for writing by Close
+	// to stop the intake, so no record is queued after Close starts draining.
+	mu     sync.RWMutex
+	closed bool
+
+	closeOnce   sync.Once
+	dropped     atomic.Uint64
+	writeErrors atomic.Uint64
+}
+
+// NewAsyncWriter creates an AsyncWriter that writes to w.
+// If config is nil, a default configuration is used.
+func NewAsyncWriter(w io.Writer, config *AsyncWriterConfig) *AsyncWriter {
+	var c AsyncWriterConfig
+	if config != nil {
+		c = *config
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = defaultAsyncQueueSize
+	}
+
+	aw := &AsyncWriter{
+		w:            w,
+		overflow:     c.Overflow,
+		onWriteError: c.OnWriteError,
+		queue:        make(chan *buffer.Buffer, c.QueueSize),
+		flushCh:      make(chan chan struct{}),
+		closing:      make(chan struct{}),
+		done:         make(chan struct{}),
+		exited:       make(chan struct{}),
+	}
+	go aw.run()
+	return aw
+}
+
+// Write queues a copy of p to be written, it never returns the error of the
+// underlying writer, see WriteErrors. Records accepted by Write are either written
+// or counted by Dropped, Write returns ErrAsyncWriterClosed once Close is called.
+func (aw *AsyncWriter) Write(p []byte) (int, error) {
+	aw.mu.RLock()
+	defer aw.mu.RUnlock()
+	if aw.closed {
+		return 0, ErrAsyncWriterClosed
+	}
+
+	buf := buffer.New()
+	buf.Write(p)
+
+	switch aw.overflow {
+	case OverflowDropNewest:
+		select {
+		case aw.queue <- buf:
+		default:
+			buf.Free()
+			aw.dropped.Add(1)
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case aw.queue <- buf:
+				return len(p), nil
+			default:
+			}
+			// queue is full, discard the oldest record
+			select {
+			case old := <-aw.queue:
+				old.Free()
+				aw.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		select {
+		case aw.queue <- buf:
+		case <-aw.closing:
+			buf.Free()
+			return 0, ErrAsyncWriterClosed
+		}
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of records discarded because the queue was full,
+// or because Close returned before they were written.
+func (aw *AsyncWriter) Dropped() uint64 {
+	return aw.dropped.Load()
+}
+
+// WriteErrors returns the number of records failed to write to the underlying writer.
+func (aw *AsyncWriter) WriteErrors() uint64 {
+	return aw.writeErrors.Load()
+}
+
+// Flush blocks until all records queued before the call are written,
+// or ctx is done.
+func (aw *AsyncWriter) Flush(ctx context.Context) error {
+	flushed := make(chan struct{})
+	select {
+	case aw.flushCh <- flushed:
+	case <-aw.exited:
+		return ErrAsyncWriterClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting writes, writes all queued records and stops the
+// background goroutine. If ctx is done first, remaining records are discarded
+// and counted by Dropped. The underlying writer is not closed.
+func (aw *AsyncWriter) Close(ctx context.Context) error {
+	err := ErrAsyncWriterClosed
+	aw.closeOnce.Do(func() {
+		// wake up the writes blocked on a full queue, then wait for the writes
+		// in progress, no record can be queued after that.
+		close(aw.closing)
+		aw.mu.Lock()
+		aw.closed = true
+		aw.mu.Unlock()
+
+		err = aw.Flush(ctx)
+		close(aw.done)
+	})
+	return err
+}
+
+func (aw *AsyncWriter) run() {
+	defer close(aw.exited)
+	for {
+		select {
+		case buf := <-aw.queue:
+			aw.write(buf)
+		case flushed := <-aw.flushCh:
+			// only drain the records queued so far, so that writers blocked
+			// on a full queue can not delay the flush forever.
+			for n := len(aw.queue); n > 0; n-- {
+				aw.write(<-aw.queue)
+			}
+			close(flushed)
+		case <-aw.done:
+			for {
+				select {
+				case buf := <-aw.queue:
+					buf.Free()
+					aw.dropped.Add(1)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (aw *AsyncWriter) write(buf *buffer.Buffer) {
+	n, err := aw.w.Write(buf.Bytes())
+	if err == nil && n < buf.Len() {
+		err = io.ErrShortWrite
+	}
+	buf.Free()
+	if err != nil {
+		aw.writeErrors.Add(1)
+		if aw.onWriteError != nil {
+			aw.onWriteError(err)
+		}
+	}
+}
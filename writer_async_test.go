@@ -0,0 +1,205 @@
+package zlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingWriter blocks every write until unblock is closed.
+type blockingWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	unblock chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.unblock
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriter(t *testing.T) {
+	w := &blockingWriter{unblock: make(chan struct{})}
+	close(w.unblock)
+	aw := NewAsyncWriter(w, nil)
+
+	log := slog.New(NewJSONHandler(&Config{
+		HandlerOptions: slog.HandlerOptions{
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					a.Key = ""
+				}
+				return a
+			},
+		},
+		Writer:               aw,
+		ConcurrentSafeWriter: true,
+	}))
+	for i := 0; i < 100; i++ {
+		log.Info("test", "i", i)
+	}
+	if err := aw.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.Split(bytes.TrimSpace([]byte(w.String())), []byte{lineEnding})
+	if len(lines) != 100 {
+		t.Fatalf("got %v lines, want %v", len(lines), 100)
+	}
+	if string(lines[99]) != `{"level":"INFO","msg":"test","i":99}` {
+		t.Errorf("got %s, want %s", lines[99], `{"level":"INFO","msg":"test","i":99}`)
+	}
+
+	if err := aw.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := aw.Write([]byte("test\n")); !errors.Is(err, ErrAsyncWriterClosed) {
+		t.Errorf("got %v, want %v", err, ErrAsyncWriterClosed)
+	}
+	if err := aw.Flush(context.Background()); !errors.Is(err, ErrAsyncWriterClosed) {
+		t.Errorf("got %v, want %v", err, ErrAsyncWriterClosed)
+	}
+	if err := aw.Close(context.Background()); !errors.Is(err, ErrAsyncWriterClosed) {
+		t.Errorf("got %v, want %v", err, ErrAsyncWriterClosed)
+	}
+}
+
+func TestAsyncWriterOverflow(t *testing.T) {
+	tests := []struct {
+		name        string
+		overflow    OverflowPolicy
+		wantDropped uint64
+		wantFirst   string
+	}{
+		{
+			name:        "drop newest",
+			overflow:    OverflowDropNewest,
+			wantDropped: 3,
+			wantFirst:   "1",
+		}, {
+			name:        "drop oldest",
+			overflow:    OverflowDropOldest,
+			wantDropped: 3,
+			wantFirst:   "4",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := &blockingWriter{unblock: make(chan struct{})}
+			aw := NewAsyncWriter(w, &AsyncWriterConfig{
+				QueueSize: 2,
+				Overflow:  test.overflow,
+			})
+			// the first record is taken by the background goroutine and blocks it
+			aw.Write([]byte("0\n"))
+			for len(aw.queue) != 0 {
+				time.Sleep(time.Millisecond)
+			}
+			for _, s := range []string{"1\n", "2\n", "3\n", "4\n", "5\n"} {
+				aw.Write([]byte(s))
+			}
+			if got := aw.Dropped(); got != test.wantDropped {
+				t.Errorf("got dropped %v, want %v", got, test.wantDropped)
+			}
+
+			close(w.unblock)
+			if err := aw.Close(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			lines := bytes.Split(bytes.TrimSpace([]byte(w.String())), []byte{lineEnding})
+			if len(lines) != 3 {
+				t.Fatalf("got %v lines, want %v", len(lines), 3)
+			}
+			if string(lines[1]) != test.wantFirst {
+				t.Errorf("got %s, want %s", lines[1], test.wantFirst)
+			}
+		})
+	}
+}
+
+func TestAsyncWriterFlushTimeout(t *testing.T) {
+	w := &blockingWriter{unblock: make(chan struct{})}
+	aw := NewAsyncWriter(w, &AsyncWriterConfig{QueueSize: 4})
+	aw.Write([]byte("0\n"))
+	aw.Write([]byte("1\n"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := aw.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+	close(w.unblock)
+}
+
+// countWriter counts the written records.
+type countWriter struct {
+	n atomic.Uint64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.n.Add(1)
+	return len(p), nil
+}
+
+func TestAsyncWriterCloseRace(t *testing.T) {
+	for _, overflow := range []OverflowPolicy{OverflowBlock, OverflowDropNewest, OverflowDropOldest} {
+		w := &countWriter{}
+		aw := NewAsyncWriter(w, &AsyncWriterConfig{QueueSize: 16, Overflow: overflow})
+		var accepted atomic.Uint64
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					if _, err := aw.Write([]byte("test\n")); err != nil {
+						return
+					}
+					accepted.Add(1)
+				}
+			}()
+		}
+		time.Sleep(10 * time.Millisecond)
+		if err := aw.Close(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		wg.Wait()
+		// every accepted record is written or dropped
+		if got, want := w.n.Load()+aw.Dropped(), accepted.Load(); got != want {
+			t.Errorf("overflow %v: got %v written and dropped, want %v accepted", overflow, got, want)
+		}
+	}
+}
+
+func TestAsyncWriterWriteErrors(t *testing.T) {
+	writeErr := errors.New("disk full")
+	var errs []error
+	aw := NewAsyncWriter(&errorWriter{err: writeErr}, &AsyncWriterConfig{
+		OnWriteError: func(err error) {
+			errs = append(errs, err)
+		},
+	})
+	aw.Write([]byte("0\n"))
+	aw.Write([]byte("1\n"))
+	if err := aw.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := aw.WriteErrors(); got != 2 {
+		t.Errorf("got %v write errors, want 2", got)
+	}
+	if len(errs) != 2 || !errors.Is(errs[0], writeErr) {
+		t.Errorf("got errors %v, want 2 of %v", errs, writeErr)
+	}
+}
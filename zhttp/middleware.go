@@ -0,0 +1,176 @@
+/*
+Package zhttp provides a net/http middleware that logs the requests by zlog.
+
+	log := zlog.New(zlog.NewJSONHandler(nil))
+	handler := zhttp.Middleware(&zhttp.Config{Logger: log})(mux)
+	http.ListenAndServe(":8080", handler)
+
+Each request is logged after it is served, with the method, path, status, bytes
+written, latency, client IP, user agent and request ID, at a level chosen by the
+status class. The request ID is added to the request context by zlog.NewContext,
+and the logger by zlog.NewLoggerContext, so the logs in the handlers have the request ID.
+
+	func hello(w http.ResponseWriter, r *http.Request) {
+		zlog.FromContext(r.Context()).Info("hello")
+	}
+*/
+package zhttp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/icefed/zlog"
+)
+
+// Config is the configuration for the middleware.
+type Config struct {
+	// Logger logs the requests, zlog.New(nil) is used if nil.
+	Logger *zlog.Logger
+	// Message is the message of the request logs, default is "http request".
+	Message string
+	// RequestIDHeader is the header of the request ID, default is "X-Request-Id".
+	// If the request has no valid request ID, a random one is generated, and it is set
+	// in the response header. A valid request ID has at most 128 letters, digits
+	// and "-", "_", ".", ":", "/", "+", "=".
+	RequestIDHeader string
+	// TrustProxyHeaders uses the X-Forwarded-For and X-Real-Ip headers as the client IP,
+	// enable it only if the server is behind a trusted proxy.
+	TrustProxyHeaders bool
+	// Level returns the level of the request log by the status, default is
+	// slog.LevelError for 5xx, slog.LevelWarn for 4xx, and slog.LevelInfo for others.
+	Level func(status int) slog.Level
+	// Skip skips logging the request if it returns true, e.g. health checks.
+	Skip func(r *http.Request) bool
+}
+
+// keys of the request log attributes.
+const (
+	MethodKey    = "method"
+	PathKey      = "path"
+	StatusKey    = "status"
+	BytesKey     = "bytes"
+	LatencyKey   = "latency"
+	ClientIPKey  = "client_ip"
+	UserAgentKey = "user_agent"
+	RequestIDKey = "request_id"
+)
+
+// DefaultLevel returns the level of the request log by the status class.
+func DefaultLevel(status int) slog.Level {
+	switch {
+	case status >= 500:
+		return slog.LevelError
+	case status >= 400:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// Middleware returns a middleware that logs the requests, and injects the request ID
+// and the logger into the request context. If c is nil, the default configuration is used.
+//
+// The request ID is added to the logs by zlog handlers that support zlog.ContextAttrs.
+func Middleware(c *Config) func(http.Handler) http.Handler {
+	var config Config
+	if c != nil {
+		config = *c
+	}
+	if config.Logger == nil {
+		config.Logger = zlog.New(nil)
+	}
+	if config.Message == "" {
+		config.Message = "http request"
+	}
+	if config.RequestIDHeader == "" {
+		config.RequestIDHeader = "X-Request-Id"
+	}
+	if config.Level == nil {
+		config.Level = DefaultLevel
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			requestID := r.Header.Get(config.RequestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = newRequestID()
+			}
+			w.Header().Set(config.RequestIDHeader, requestID)
+
+			ctx := zlog.NewContext(r.Context(), slog.String(RequestIDKey, requestID))
+			r = r.WithContext(zlog.NewLoggerContext(ctx, config.Logger))
+
+			rw := &responseWriter{ResponseWriter: w}
+			next.ServeHTTP(rw, r)
+
+			if config.Skip != nil && config.Skip(r) {
+				return
+			}
+			status := rw.Status()
+			config.Logger.LogAttrs(r.Context(), config.Level(status), config.Message,
+				slog.String(MethodKey, r.Method),
+				slog.String(PathKey, r.URL.Path),
+				slog.Int(StatusKey, status),
+				slog.Int64(BytesKey, rw.BytesWritten()),
+				slog.Duration(LatencyKey, time.Since(start)),
+				slog.String(ClientIPKey, clientIP(r, config.TrustProxyHeaders)),
+				slog.String(UserAgentKey, r.UserAgent()),
+			)
+		})
+	}
+}
+
+// newRequestID returns a random request ID of 32 hex characters.
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// maxRequestIDLength is the maximum length of the request IDs from the clients.
+const maxRequestIDLength = 128
+
+// validRequestID reports whether the request ID from the client is not empty, not
+// longer than maxRequestIDLength, and has only the characters allowed.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':', c == '/', c == '+', c == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// clientIP returns the IP of the client, the first address of X-Forwarded-For or
+// X-Real-Ip is used if trustProxy is true.
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			ip, _, _ := strings.Cut(xff, ",")
+			return strings.TrimSpace(ip)
+		}
+		if ip := r.Header.Get("X-Real-Ip"); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,174 @@
+package zhttp
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/icefed/zlog"
+	"github.com/icefed/zlog/zlogtest"
+)
+
+func TestMiddleware(t *testing.T) {
+	h, logs := zlogtest.New(slog.LevelDebug)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		zlog.FromContext(r.Context()).Info("hello")
+		w.Write([]byte("Hello, World!"))
+	})
+	mux.HandleFunc("/error", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+	handler := Middleware(&Config{
+		Logger:            zlog.New(h),
+		TrustProxyHeaders: true,
+		Skip: func(r *http.Request) bool {
+			return r.URL.Path == "/healthz"
+		},
+	})(mux)
+
+	tests := []struct {
+		name   string
+		path   string
+		header http.Header
+		level  slog.Level
+		status int
+		bytes  int64
+		ip     string
+	}{
+		{
+			name:   "ok",
+			path:   "/hello",
+			header: http.Header{"X-Request-Id": {"abc"}, "User-Agent": {"test"}},
+			level:  slog.LevelInfo,
+			status: http.StatusOK,
+			bytes:  13,
+			ip:     "192.0.2.1",
+		}, {
+			name:   "not found",
+			path:   "/notfound",
+			header: http.Header{"X-Forwarded-For": {"10.0.0.1, 10.0.0.2"}},
+			level:  slog.LevelWarn,
+			status: http.StatusNotFound,
+			bytes:  19,
+			ip:     "10.0.0.1",
+		}, {
+			name:   "error",
+			path:   "/error",
+			header: http.Header{"X-Real-Ip": {"10.0.0.3"}},
+			level:  slog.LevelError,
+			status: http.StatusInternalServerError,
+			bytes:  15,
+			ip:     "10.0.0.3",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			req.Header = test.header
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			requestID := rec.Header().Get("X-Request-Id")
+			if want := test.header.Get("X-Request-Id"); want != "" && requestID != want {
+				t.Errorf("got request id %q, want %q", requestID, want)
+			}
+			if len(requestID) == 0 {
+				t.Error("request id is not set")
+			}
+			entries := logs.FilterMessage("http request").All()
+			if test.name == "ok" {
+				// the request-scoped logger has the request id
+				if n := logs.FilterMessage("hello").FilterAttr(RequestIDKey, "abc").Len(); n != 1 {
+					t.Errorf("got %d hello entries, want 1", n)
+				}
+			}
+			logs.TakeAll()
+			if len(entries) != 1 {
+				t.Fatalf("got %d entries, want 1", len(entries))
+			}
+			e := entries[0]
+			if e.Level != test.level {
+				t.Errorf("got level %v, want %v", e.Level, test.level)
+			}
+			m := e.AttrMap()
+			expected := map[string]any{
+				MethodKey:    http.MethodGet,
+				PathKey:      test.path,
+				StatusKey:    int64(test.status),
+				BytesKey:     test.bytes,
+				ClientIPKey:  test.ip,
+				UserAgentKey: test.header.Get("User-Agent"),
+				RequestIDKey: requestID,
+			}
+			for k, v := range expected {
+				if m[k] != v {
+					t.Errorf("got %s %v, want %v", k, m[k], v)
+				}
+			}
+			if _, ok := m[LatencyKey].(time.Duration); !ok {
+				t.Errorf("got latency %v, want time.Duration", m[LatencyKey])
+			}
+		})
+	}
+
+	// skipped
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if logs.Len() != 0 {
+		t.Errorf("got %d entries, want 0", logs.Len())
+	}
+}
+
+func TestDefaultLevel(t *testing.T) {
+	tests := []struct {
+		status int
+		level  slog.Level
+	}{
+		{http.StatusOK, slog.LevelInfo},
+		{http.StatusMovedPermanently, slog.LevelInfo},
+		{http.StatusBadRequest, slog.LevelWarn},
+		{http.StatusServiceUnavailable, slog.LevelError},
+	}
+	for _, test := range tests {
+		if got := DefaultLevel(test.status); got != test.level {
+			t.Errorf("DefaultLevel(%d) = %v, want %v", test.status, got, test.level)
+		}
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{"6b1e4f0ad3e2a9d4", true},
+		{"req-1_a.b:c/d+e=", true},
+		{"", false},
+		{strings.Repeat("a", maxRequestIDLength), true},
+		{strings.Repeat("a", maxRequestIDLength+1), false},
+		{"abc def", false},
+		{"abc\"}", false},
+		{"abc\n", false},
+		{"中文", false},
+	}
+	for _, test := range tests {
+		if got := validRequestID(test.id); got != test.valid {
+			t.Errorf("validRequestID(%q) = %v, want %v", test.id, got, test.valid)
+		}
+	}
+
+	// invalid request IDs are replaced
+	h, _ := zlogtest.New(slog.LevelDebug)
+	handler := Middleware(&Config{Logger: zlog.New(h)})(http.NotFoundHandler())
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-Id", strings.Repeat("x", 1000))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get("X-Request-Id"); len(got) != 32 {
+		t.Errorf("got request id %q, want a generated one", got)
+	}
+}
@@ -0,0 +1,91 @@
+package zhttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &responseWriter{ResponseWriter: rec}
+	if w.Status() != http.StatusOK {
+		t.Errorf("got status %d, want %d", w.Status(), http.StatusOK)
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("hello"))
+	w.Write([]byte("world"))
+	w.Flush()
+	if w.Status() != http.StatusCreated {
+		t.Errorf("got status %d, want %d", w.Status(), http.StatusCreated)
+	}
+	if w.BytesWritten() != 10 {
+		t.Errorf("got bytes %d, want 10", w.BytesWritten())
+	}
+	if !rec.Flushed {
+		t.Error("not flushed")
+	}
+	if w.Unwrap() != rec {
+		t.Error("Unwrap does not return the wrapped writer")
+	}
+
+	// 1xx informational responses are not the final status
+	w = &responseWriter{ResponseWriter: httptest.NewRecorder()}
+	w.WriteHeader(http.StatusEarlyHints)
+	w.WriteHeader(http.StatusNoContent)
+	if w.Status() != http.StatusNoContent {
+		t.Errorf("got status %d, want %d", w.Status(), http.StatusNoContent)
+	}
+
+	// httptest.ResponseRecorder does not implement http.Hijacker
+	if _, _, err := w.Hijack(); err == nil {
+		t.Error("want hijack error")
+	}
+	// http.ResponseController works with Unwrap
+	w = &responseWriter{ResponseWriter: rec}
+	if err := http.NewResponseController(w).Flush(); err != nil {
+		t.Error(err)
+	}
+}
+
+// readerFromRecorder records whether ReadFrom of the wrapped writer is used.
+type readerFromRecorder struct {
+	*httptest.ResponseRecorder
+	called bool
+}
+
+func (w *readerFromRecorder) ReadFrom(r io.Reader) (int64, error) {
+	w.called = true
+	return io.Copy(w.ResponseRecorder, r)
+}
+
+func TestResponseWriterReadFrom(t *testing.T) {
+	rec := &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	w := &responseWriter{ResponseWriter: rec}
+	// LimitReader hides strings.Reader.WriteTo, so io.Copy calls ReadFrom
+	if n, err := io.Copy(w, io.LimitReader(strings.NewReader("hello"), 5)); err != nil || n != 5 {
+		t.Fatalf("got %d %v, want 5", n, err)
+	}
+	if !rec.called {
+		t.Error("ReadFrom of the wrapped writer is not used")
+	}
+
+	// the wrapped writer without ReadFrom
+	plain := httptest.NewRecorder()
+	w2 := &responseWriter{ResponseWriter: plain}
+	if _, err := w2.ReadFrom(strings.NewReader("world")); err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []*responseWriter{w, w2} {
+		if w.Status() != http.StatusOK || w.BytesWritten() != 5 {
+			t.Errorf("got status %d bytes %d, want 200 and 5", w.Status(), w.BytesWritten())
+		}
+	}
+	if plain.Body.String() != "world" {
+		t.Errorf("got %q, want %q", plain.Body.String(), "world")
+	}
+}
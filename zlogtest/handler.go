@@ -0,0 +1,119 @@
+/*
+Package zlogtest provides handlers for asserting and printing logs in unit tests.
+
+Handler records entries in memory, so tests can query them instead of parsing
+the output of a writer.
+
+	h, logs := zlogtest.New(slog.LevelDebug)
+	log := zlog.New(h)
+	log.Info("user login", "user", "john")
+
+	entries := logs.FilterMessage("user login").FilterAttr("user", "john").TakeAll()
+	if len(entries) != 1 {
+		t.Errorf("got %d entries, want 1", len(entries))
+	}
+
+NewTBHandler writes logs by testing.TB.Log, so they are printed only when the test
+fails or runs in verbose mode.
+
+	log := zlog.New(zlogtest.NewTBHandler(t))
+*/
+package zlogtest
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"github.com/icefed/zlog"
+	"github.com/icefed/zlog/internal/groupattrs"
+)
+
+var (
+	_ slog.Handler      = (*Handler)(nil)
+	_ zlog.NamedHandler = (*Handler)(nil)
+)
+
+// Handler implements the slog.Handler interface, it records the entries in Logs.
+type Handler struct {
+	level slog.Leveler
+	logs  *Logs
+
+	name  string
+	attrs groupattrs.Attrs
+}
+
+// New creates a Handler that records the entries at level or above, and the Logs
+// that holds the entries. If level is nil, slog.LevelInfo is used.
+func New(level slog.Leveler) (*Handler, *Logs) {
+	if level == nil {
+		level = slog.LevelInfo
+	}
+	logs := &Logs{}
+	return &Handler{
+		level: level,
+		logs:  logs,
+	}, logs
+}
+
+// Enabled reports whether the handler handles records at the given level.
+// https://pkg.go.dev/log/slog#Handler
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle records the entry of r.
+// https://pkg.go.dev/log/slog#Handler
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	// attributes added by zlog.NewContext are included, like zlog.JSONHandler
+	attrs := h.attrs.Resolve(zlog.ContextAttrs(ctx), r)
+
+	entry := Entry{
+		Time:       r.Time,
+		Level:      r.Level,
+		Message:    r.Message,
+		LoggerName: h.name,
+		Attrs:      attrs,
+		Context:    ctx,
+	}
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		entry.Source = &slog.Source{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+		}
+	}
+	h.logs.add(entry)
+	return nil
+}
+
+// WithAttrs implements the slog.Handler WithAttrs method.
+// https://pkg.go.dev/log/slog#Handler
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	newHandler := *h
+	newHandler.attrs = h.attrs.WithAttrs(attrs)
+	return &newHandler
+}
+
+// WithGroup implements the slog.Handler WithGroup method.
+// https://pkg.go.dev/log/slog#Handler
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	newHandler := *h
+	newHandler.attrs = h.attrs.WithGroup(name)
+	return &newHandler
+}
+
+// WithName implements the zlog.NamedHandler interface, the name is recorded
+// as Entry.LoggerName.
+func (h *Handler) WithName(name string) slog.Handler {
+	newHandler := *h
+	newHandler.name = name
+	return &newHandler
+}
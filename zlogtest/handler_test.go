@@ -0,0 +1,113 @@
+package zlogtest
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/icefed/zlog"
+)
+
+func TestHandlerSlogtest(t *testing.T) {
+	h, logs := New(slog.LevelDebug)
+	err := slogtest.TestHandler(h, func() []map[string]any {
+		var results []map[string]any
+		for _, e := range logs.All() {
+			m := attrsToMap(e.Attrs)
+			if !e.Time.IsZero() {
+				m[slog.TimeKey] = e.Time
+			}
+			m[slog.LevelKey] = e.Level
+			m[slog.MessageKey] = e.Message
+			results = append(results, m)
+		}
+		return results
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func attrsToMap(attrs []slog.Attr) map[string]any {
+	m := make(map[string]any)
+	for _, a := range attrs {
+		if a.Value.Kind() == slog.KindGroup {
+			m[a.Key] = attrsToMap(a.Value.Group())
+			continue
+		}
+		m[a.Key] = a.Value.Any()
+	}
+	return m
+}
+
+type lazyValue string
+
+func (v lazyValue) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("value", string(v)))
+}
+
+func TestHandler(t *testing.T) {
+	h, logs := New(nil)
+	log := zlog.New(h).Named("db").Named("pool")
+	if log.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("want debug level disabled")
+	}
+
+	ctx := context.WithValue(context.Background(), struct{}{}, "test")
+	log.With("app", "test").WithGroup("request").With("method", "GET").
+		InfoContext(ctx, "request", "lazy", lazyValue("v"), slog.Group("", "inline", 1))
+	_, file, line, _ := runtime.Caller(0)
+
+	entries := logs.TakeAll()
+	if len(entries) != 1 || logs.Len() != 0 {
+		t.Fatalf("got %d entries and %d left, want 1 and 0", len(entries), logs.Len())
+	}
+	e := entries[0]
+	if e.Level != slog.LevelInfo || e.Message != "request" || e.LoggerName != "db.pool" || e.Context != ctx {
+		t.Errorf("got entry %+v", e)
+	}
+	if time.Since(e.Time) > time.Minute {
+		t.Errorf("got time %v", e.Time)
+	}
+	if e.Source == nil || e.Source.File != file || e.Source.Line != line-1 {
+		t.Errorf("got source %+v, want %s:%d", e.Source, file, line-1)
+	}
+
+	want := map[string]any{
+		"app":                "test",
+		"request.method":     "GET",
+		"request.lazy.value": "v",
+		"request.inline":     int64(1),
+	}
+	got := e.AttrMap()
+	if len(got) != len(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("got %v=%v, want %v", k, got[k], v)
+		}
+	}
+	if v, ok := e.Lookup("request.lazy"); !ok || v.Kind() != slog.KindGroup {
+		t.Errorf("got %v, want group", v)
+	}
+
+	log.With("app", "test").WithGroup("empty").Info("empty group")
+	if attrs := logs.TakeAll()[0].Attrs; len(attrs) != 1 {
+		t.Errorf("got %v, want empty group ignored", attrs)
+	}
+}
+
+func TestHandlerContextAttrs(t *testing.T) {
+	h, logs := New(nil)
+	ctx := zlog.NewContext(context.Background(), "request_id", "abc")
+	slog.New(h).WithGroup("g").InfoContext(ctx, "test", "k", "v")
+
+	entries := logs.FilterAttr("g.request_id", "abc").FilterAttr("g.k", "v").TakeAll()
+	if len(entries) != 1 {
+		t.Errorf("got %d entries, want 1", len(entries))
+	}
+}
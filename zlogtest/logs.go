@@ -0,0 +1,175 @@
+package zlogtest
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a log record recorded by Handler.
+type Entry struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	// LoggerName is the name set by zlog.Logger.Named.
+	LoggerName string
+	// Source is the source of the record, nil if the record has no pc.
+	Source *slog.Source
+	// Attrs is the resolved attributes tree, attributes added by WithAttrs and the
+	// record are nested in the groups added by WithGroup.
+	Attrs []slog.Attr
+	// Context is the context passed to Handle.
+	Context context.Context
+}
+
+// Lookup returns the value of the attribute by its path, group names and the key
+// are separated by ".", e.g. "request.method".
+func (e Entry) Lookup(path string) (slog.Value, bool) {
+	return lookup(e.Attrs, path)
+}
+
+func lookup(attrs []slog.Attr, path string) (slog.Value, bool) {
+	for _, a := range attrs {
+		if a.Key == path {
+			return a.Value, true
+		}
+		if a.Value.Kind() == slog.KindGroup && strings.HasPrefix(path, a.Key+".") {
+			if v, ok := lookup(a.Value.Group(), path[len(a.Key)+1:]); ok {
+				return v, true
+			}
+		}
+	}
+	return slog.Value{}, false
+}
+
+// AttrMap returns the attributes as a flat map, keys are the paths of attributes,
+// values are the values of slog.Value.Any, e.g. {"request.method": "GET"}.
+func (e Entry) AttrMap() map[string]any {
+	m := make(map[string]any)
+	var walk func(prefix string, attrs []slog.Attr)
+	walk = func(prefix string, attrs []slog.Attr) {
+		for _, a := range attrs {
+			if a.Value.Kind() == slog.KindGroup {
+				walk(prefix+a.Key+".", a.Value.Group())
+				continue
+			}
+			m[prefix+a.Key] = a.Value.Any()
+		}
+	}
+	walk("", e.Attrs)
+	return m
+}
+
+// Logs holds the entries recorded by Handler, it is safe for concurrent use.
+type Logs struct {
+	mu      sync.RWMutex
+	entries []Entry
+}
+
+func (l *Logs) add(e Entry) {
+	l.mu.Lock()
+	l.entries = append(l.entries, e)
+	l.mu.Unlock()
+}
+
+// Len returns the number of entries.
+func (l *Logs) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.entries)
+}
+
+// All returns a copy of all the entries.
+func (l *Logs) All() []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return append([]Entry(nil), l.entries...)
+}
+
+// TakeAll returns all the entries and removes them from the Logs.
+func (l *Logs) TakeAll() []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries := l.entries
+	l.entries = nil
+	return entries
+}
+
+// Filter returns a new Logs with the entries that f returns true.
+func (l *Logs) Filter(f func(Entry) bool) *Logs {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	filtered := &Logs{}
+	for _, e := range l.entries {
+		if f(e) {
+			filtered.entries = append(filtered.entries, e)
+		}
+	}
+	return filtered
+}
+
+// FilterMessage returns a new Logs with the entries whose message is msg.
+func (l *Logs) FilterMessage(msg string) *Logs {
+	return l.Filter(func(e Entry) bool {
+		return e.Message == msg
+	})
+}
+
+// FilterMessageSnippet returns a new Logs with the entries whose message contains snippet.
+func (l *Logs) FilterMessageSnippet(snippet string) *Logs {
+	return l.Filter(func(e Entry) bool {
+		return strings.Contains(e.Message, snippet)
+	})
+}
+
+// FilterLevel returns a new Logs with the entries at the level.
+func (l *Logs) FilterLevel(level slog.Level) *Logs {
+	return l.Filter(func(e Entry) bool {
+		return e.Level == level
+	})
+}
+
+// FilterAttr returns a new Logs with the entries that have the attribute at the path
+// with the value, see Entry.Lookup. The value is compared after converted by
+// slog.AnyValue, so FilterAttr("count", 1) matches slog.Int64("count", 1).
+func (l *Logs) FilterAttr(path string, value any) *Logs {
+	want := slog.AnyValue(value)
+	return l.Filter(func(e Entry) bool {
+		v, ok := e.Lookup(path)
+		return ok && valueEqual(v, want)
+	})
+}
+
+// FilterAttrKey returns a new Logs with the entries that have the attribute at the path.
+func (l *Logs) FilterAttrKey(path string) *Logs {
+	return l.Filter(func(e Entry) bool {
+		_, ok := e.Lookup(path)
+		return ok
+	})
+}
+
+func valueEqual(v, w slog.Value) bool {
+	if v.Kind() != w.Kind() {
+		return false
+	}
+	switch v.Kind() {
+	case slog.KindAny:
+		return reflect.DeepEqual(v.Any(), w.Any())
+	case slog.KindGroup:
+		vg, wg := v.Group(), w.Group()
+		if len(vg) != len(wg) {
+			return false
+		}
+		for i := range vg {
+			if vg[i].Key != wg[i].Key || !valueEqual(vg[i].Value, wg[i].Value) {
+				return false
+			}
+		}
+		return true
+	default:
+		return v.Equal(w)
+	}
+}
@@ -0,0 +1,49 @@
+package zlogtest
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/icefed/zlog"
+)
+
+func TestLogs(t *testing.T) {
+	h, logs := New(slog.LevelDebug)
+	log := zlog.New(h)
+	log.Debug("request", "method", "GET", "code", 200)
+	log.WithGroup("request").Info("request", "method", "POST", "code", 500, "tags", []string{"a"})
+	log.Warn("request failed", "dotted.key", "value")
+
+	tests := []struct {
+		name string
+		logs *Logs
+		want int
+	}{
+		{"all", logs, 3},
+		{"message", logs.FilterMessage("request"), 2},
+		{"message snippet", logs.FilterMessageSnippet("request"), 3},
+		{"level", logs.FilterLevel(slog.LevelWarn), 1},
+		{"attr", logs.FilterAttr("code", 200), 1},
+		{"attr in group", logs.FilterAttr("request.code", 500), 1},
+		{"attr any", logs.FilterAttr("request.tags", []string{"a"}), 1},
+		{"attr not equal", logs.FilterAttr("code", "200"), 0},
+		{"attr dotted key", logs.FilterAttr("dotted.key", "value"), 1},
+		{"attr key", logs.FilterAttrKey("method"), 1},
+		{"chained", logs.FilterMessage("request").FilterAttrKey("request"), 1},
+		{"custom", logs.Filter(func(e Entry) bool { return e.Level >= slog.LevelInfo }), 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.logs.Len(); got != test.want {
+				t.Errorf("got %d entries, want %d", got, test.want)
+			}
+		})
+	}
+
+	if len(logs.All()) != 3 {
+		t.Error("want All not to remove entries")
+	}
+	if len(logs.TakeAll()) != 3 || logs.Len() != 0 {
+		t.Error("want TakeAll to remove entries")
+	}
+}
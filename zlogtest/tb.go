@@ -0,0 +1,54 @@
+package zlogtest
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/icefed/zlog"
+)
+
+// NewTBHandler creates a zlog.TextHandler that writes logs by t.Log, the level is
+// slog.LevelDebug by default, and can be changed by the options.
+// Logs written after the test finished are dropped, t.Log panics in this case.
+func NewTBHandler(t testing.TB, opts ...zlog.Option) *zlog.TextHandler {
+	return zlog.NewTextHandler(&zlog.Config{
+		HandlerOptions: slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		},
+		Writer: newTBWriter(t),
+	}).WithOptions(opts...)
+}
+
+// NewLogger creates a zlog.Logger with the handler created by NewTBHandler.
+func NewLogger(t testing.TB, opts ...zlog.Option) *zlog.Logger {
+	return zlog.New(NewTBHandler(t, opts...))
+}
+
+// tbWriter writes each record by t.Log.
+type tbWriter struct {
+	t testing.TB
+
+	mu   sync.RWMutex
+	done bool
+}
+
+func newTBWriter(t testing.TB) *tbWriter {
+	w := &tbWriter{t: t}
+	t.Cleanup(func() {
+		w.mu.Lock()
+		w.done = true
+		w.mu.Unlock()
+	})
+	return w
+}
+
+func (w *tbWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if !w.done {
+		w.t.Log(strings.TrimSuffix(string(p), "\n"))
+	}
+	return len(p), nil
+}
@@ -0,0 +1,56 @@
+package zlogtest
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/icefed/zlog"
+)
+
+// recordTB records the output of Log.
+type recordTB struct {
+	testing.TB
+	logs     []string
+	cleanups []func()
+}
+
+func (tb *recordTB) Log(args ...any) {
+	tb.logs = append(tb.logs, fmt.Sprint(args...))
+}
+
+func (tb *recordTB) Cleanup(f func()) {
+	tb.cleanups = append(tb.cleanups, f)
+}
+
+func TestTBHandler(t *testing.T) {
+	tb := &recordTB{TB: t}
+	log := NewLogger(tb, zlog.WithReplaceAttr(func(_ []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey {
+			return slog.Attr{}
+		}
+		return a
+	}))
+	log.Debug("hello world", "key", "value")
+	log.Named("db").Info("connected")
+
+	want := []string{
+		"level=DEBUG msg=\"hello world\" key=value",
+		"level=INFO logger=db msg=connected",
+	}
+	if strings.Join(tb.logs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q, want %q", tb.logs, want)
+	}
+
+	// logs after the test finished are dropped
+	for _, f := range tb.cleanups {
+		f()
+	}
+	log.Info("dropped")
+	if len(tb.logs) != 2 {
+		t.Errorf("got %d logs, want 2", len(tb.logs))
+	}
+
+	NewLogger(t).Info("printed by t.Log")
+}
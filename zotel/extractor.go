@@ -0,0 +1,122 @@
+/*
+Package zotel integrates zlog with OpenTelemetry.
+
+NewContextExtractor returns a zlog.ContextExtractor that adds the trace context of
+the span in the context to the logs, with the field names of W3C, Datadog or GCP.
+
+	h := zlog.NewJSONHandler(&zlog.Config{
+		ContextExtractors: []zlog.ContextExtractor{zotel.NewContextExtractor(zotel.W3C)},
+	})
+	log := zlog.New(h)
+	// {"msg":"hello","trace_id":"95f0717d9da16177176efdbc7c06bfbd","span_id":"7718edf7b2a8388d","trace_flags":"01"}
+	log.InfoContext(ctx, "hello")
+
+SpanEventHandler adds the records as events to the span in the context, and
+LogHandler emits the records as OpenTelemetry log records to a LoggerProvider,
+so the logs are correlated with the traces.
+*/
+package zotel
+
+import (
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"strconv"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/icefed/zlog"
+)
+
+// Format defines the field names and values of the trace context.
+type Format struct {
+	// Group nests the fields in a group if it is not empty.
+	Group string
+	// TraceIDKey is the key of the trace ID field.
+	TraceIDKey string
+	// SpanIDKey is the key of the span ID field.
+	SpanIDKey string
+	// TraceFlagsKey is the key of the trace flags field, the field is omitted if it is empty.
+	TraceFlagsKey string
+	// FormatTraceID formats the trace ID, the hex string is used if nil.
+	FormatTraceID func(trace.TraceID) string
+	// FormatSpanID formats the span ID, the hex string is used if nil.
+	FormatSpanID func(trace.SpanID) string
+	// SampledFlag writes the sampled flag as a bool instead of the hex trace flags, e.g. "01".
+	SampledFlag bool
+}
+
+var (
+	// W3C is the format of W3C Trace Context and OpenTelemetry, e.g.
+	// {"trace_id":"95f0717d9da16177176efdbc7c06bfbd","span_id":"7718edf7b2a8388d","trace_flags":"01"}.
+	W3C = Format{
+		TraceIDKey:    "trace_id",
+		SpanIDKey:     "span_id",
+		TraceFlagsKey: "trace_flags",
+	}
+	// Datadog is the format of Datadog, IDs are the decimal of the lower 64 bits, e.g.
+	// {"dd":{"trace_id":"1688565896287010749","span_id":"8581870738064554125"}}.
+	Datadog = Format{
+		Group:      "dd",
+		TraceIDKey: "trace_id",
+		SpanIDKey:  "span_id",
+		FormatTraceID: func(id trace.TraceID) string {
+			return strconv.FormatUint(binary.BigEndian.Uint64(id[8:]), 10)
+		},
+		FormatSpanID: func(id trace.SpanID) string {
+			return strconv.FormatUint(binary.BigEndian.Uint64(id[:]), 10)
+		},
+	}
+)
+
+// GCP returns the format of Google Cloud Logging, the trace ID is the resource name
+// of the trace in the project, e.g.
+// {"logging.googleapis.com/trace":"projects/my-project/traces/95f0717d9da16177176efdbc7c06bfbd",
+// "logging.googleapis.com/spanId":"7718edf7b2a8388d","logging.googleapis.com/trace_sampled":true}.
+func GCP(projectID string) Format {
+	return Format{
+		TraceIDKey:    "logging.googleapis.com/trace",
+		SpanIDKey:     "logging.googleapis.com/spanId",
+		TraceFlagsKey: "logging.googleapis.com/trace_sampled",
+		FormatTraceID: func(id trace.TraceID) string {
+			if projectID == "" {
+				return id.String()
+			}
+			return "projects/" + projectID + "/traces/" + id.String()
+		},
+		SampledFlag: true,
+	}
+}
+
+// NewContextExtractor returns a zlog.ContextExtractor that extracts the trace context
+// of the span in the context in the format, nothing is extracted if the span context is invalid.
+func NewContextExtractor(format Format) zlog.ContextExtractor {
+	return func(ctx context.Context) []slog.Attr {
+		sc := trace.SpanContextFromContext(ctx)
+		if !sc.IsValid() {
+			return nil
+		}
+		attrs := make([]slog.Attr, 0, 3)
+		traceID := sc.TraceID().String()
+		if format.FormatTraceID != nil {
+			traceID = format.FormatTraceID(sc.TraceID())
+		}
+		attrs = append(attrs, slog.String(format.TraceIDKey, traceID))
+		spanID := sc.SpanID().String()
+		if format.FormatSpanID != nil {
+			spanID = format.FormatSpanID(sc.SpanID())
+		}
+		attrs = append(attrs, slog.String(format.SpanIDKey, spanID))
+		if format.TraceFlagsKey != "" {
+			if format.SampledFlag {
+				attrs = append(attrs, slog.Bool(format.TraceFlagsKey, sc.IsSampled()))
+			} else {
+				attrs = append(attrs, slog.String(format.TraceFlagsKey, sc.TraceFlags().String()))
+			}
+		}
+		if format.Group != "" {
+			return []slog.Attr{{Key: format.Group, Value: slog.GroupValue(attrs...)}}
+		}
+		return attrs
+	}
+}
@@ -0,0 +1,81 @@
+package zotel
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/icefed/zlog"
+)
+
+var (
+	testTraceID, _ = trace.TraceIDFromHex("95f0717d9da16177176efdbc7c06bfbd")
+	testSpanID, _  = trace.SpanIDFromHex("7718edf7b2a8388d")
+)
+
+func testSpanContext() context.Context {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    testTraceID,
+		SpanID:     testSpanID,
+		TraceFlags: trace.FlagsSampled,
+	})
+	return trace.ContextWithSpanContext(context.Background(), sc)
+}
+
+func TestNewContextExtractor(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		expected string
+	}{
+		{
+			name:     "w3c",
+			format:   W3C,
+			expected: `{"level":"INFO","msg":"test","trace_id":"95f0717d9da16177176efdbc7c06bfbd","span_id":"7718edf7b2a8388d","trace_flags":"01"}`,
+		}, {
+			name:     "datadog",
+			format:   Datadog,
+			expected: `{"level":"INFO","msg":"test","dd":{"trace_id":"1688565896287010749","span_id":"8581870738064554125"}}`,
+		}, {
+			name:     "gcp",
+			format:   GCP("my-project"),
+			expected: `{"level":"INFO","msg":"test","logging.googleapis.com/trace":"projects/my-project/traces/95f0717d9da16177176efdbc7c06bfbd","logging.googleapis.com/spanId":"7718edf7b2a8388d","logging.googleapis.com/trace_sampled":true}`,
+		}, {
+			name:     "gcp without project",
+			format:   GCP(""),
+			expected: `{"level":"INFO","msg":"test","logging.googleapis.com/trace":"95f0717d9da16177176efdbc7c06bfbd","logging.googleapis.com/spanId":"7718edf7b2a8388d","logging.googleapis.com/trace_sampled":true}`,
+		},
+	}
+	ctx := testSpanContext()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			h := zlog.NewJSONHandler(&zlog.Config{
+				HandlerOptions: slog.HandlerOptions{
+					ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+						if a.Key == slog.TimeKey {
+							return slog.Attr{}
+						}
+						return a
+					},
+				},
+				Writer:            buf,
+				ContextExtractors: []zlog.ContextExtractor{NewContextExtractor(test.format)},
+			})
+			slog.New(h).InfoContext(ctx, "test")
+			if got := strings.TrimSuffix(buf.String(), "\n"); got != test.expected {
+				t.Errorf("got %s, want %s", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestNewContextExtractorInvalidSpan(t *testing.T) {
+	if attrs := NewContextExtractor(W3C)(context.Background()); attrs != nil {
+		t.Errorf("got %v, want nil", attrs)
+	}
+}
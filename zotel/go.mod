@@ -0,0 +1,23 @@
+module github.com/icefed/zlog/zotel
+
+go 1.21
+
+// The replace only applies when developing in this repository, users of zotel get
+// the tagged zlog release required below, see the release steps of zotel in the README.
+replace github.com/icefed/zlog => ../
+
+require (
+	github.com/icefed/zlog v0.3.0
+	go.opentelemetry.io/otel v1.27.0
+	go.opentelemetry.io/otel/log v0.3.0
+	go.opentelemetry.io/otel/sdk v1.27.0
+	go.opentelemetry.io/otel/trace v1.27.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	go.opentelemetry.io/otel/metric v1.27.0 // indirect
+	golang.org/x/sys v0.20.0 // indirect
+	golang.org/x/term v0.18.0 // indirect
+)
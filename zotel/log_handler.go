@@ -0,0 +1,154 @@
+package zotel
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/log"
+
+	"github.com/icefed/zlog"
+	"github.com/icefed/zlog/internal/groupattrs"
+)
+
+var (
+	_ slog.Handler      = (*LogHandler)(nil)
+	_ zlog.NamedHandler = (*LogHandler)(nil)
+)
+
+// LogHandler implements the slog.Handler interface, it emits the records as
+// OpenTelemetry log records to a logger of the LoggerProvider. The records are
+// emitted with the context, so the SDK correlates them with the span in the context.
+//
+// The severity is mapped from the level, slog.LevelDebug, slog.LevelInfo, slog.LevelWarn
+// and slog.LevelError are mapped to log.SeverityDebug, log.SeverityInfo, log.SeverityWarn
+// and log.SeverityError, the levels between them are mapped to the severities between them.
+// The severity text is the level name of zlog.LevelString, e.g. "NOTICE", "FATAL".
+type LogHandler struct {
+	provider log.LoggerProvider
+	logger   log.Logger
+	level    slog.Leveler
+
+	name  string
+	attrs groupattrs.Attrs
+}
+
+// NewLogHandler creates a LogHandler that emits the records at level or above to the
+// logger named name of provider, slog.LevelInfo is used if level is nil.
+func NewLogHandler(provider log.LoggerProvider, name string, level slog.Leveler) *LogHandler {
+	if level == nil {
+		level = slog.LevelInfo
+	}
+	return &LogHandler{
+		provider: provider,
+		logger:   provider.Logger(name),
+		level:    level,
+		name:     name,
+	}
+}
+
+// Enabled reports whether the records at the level are emitted.
+// https://pkg.go.dev/log/slog#Handler
+func (h *LogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if level < h.level.Level() {
+		return false
+	}
+	var r log.Record
+	r.SetSeverity(Severity(level))
+	return h.logger.Enabled(ctx, r)
+}
+
+// Handle emits the record as an OpenTelemetry log record.
+// https://pkg.go.dev/log/slog#Handler
+func (h *LogHandler) Handle(ctx context.Context, r slog.Record) error {
+	var record log.Record
+	record.SetTimestamp(r.Time)
+	record.SetObservedTimestamp(time.Now())
+	record.SetSeverity(Severity(r.Level))
+	record.SetSeverityText(zlog.LevelString(r.Level))
+	record.SetBody(log.StringValue(r.Message))
+	for _, a := range h.attrs.Resolve(zlog.ContextAttrs(ctx), r) {
+		record.AddAttributes(log.KeyValue{Key: a.Key, Value: logValue(a.Value)})
+	}
+	h.logger.Emit(ctx, record)
+	return nil
+}
+
+// WithAttrs implements the slog.Handler WithAttrs method.
+// https://pkg.go.dev/log/slog#Handler
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	newHandler := *h
+	newHandler.attrs = h.attrs.WithAttrs(attrs)
+	return &newHandler
+}
+
+// WithGroup implements the slog.Handler WithGroup method.
+// https://pkg.go.dev/log/slog#Handler
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	newHandler := *h
+	newHandler.attrs = h.attrs.WithGroup(name)
+	return &newHandler
+}
+
+// WithName implements the zlog.NamedHandler interface, the records are emitted to
+// the logger named name of the provider, which is the instrumentation scope name.
+func (h *LogHandler) WithName(name string) slog.Handler {
+	newHandler := *h
+	newHandler.name = name
+	newHandler.logger = h.provider.Logger(name)
+	return &newHandler
+}
+
+// Severity returns the OpenTelemetry severity of the level, the levels out of the
+// severity range are mapped to log.SeverityTrace1 or log.SeverityFatal4.
+func Severity(level slog.Level) log.Severity {
+	// slog.LevelInfo(0) is log.SeverityInfo(9), slog levels are 4 apart, and the
+	// severities of each range are 4 apart too.
+	s := int(level) + int(log.SeverityInfo)
+	switch {
+	case s < int(log.SeverityTrace1):
+		return log.SeverityTrace1
+	case s > int(log.SeverityFatal4):
+		return log.SeverityFatal4
+	default:
+		return log.Severity(s)
+	}
+}
+
+// logValue returns the OpenTelemetry log value of the slog value.
+func logValue(v slog.Value) log.Value {
+	switch v.Kind() {
+	case slog.KindGroup:
+		group := v.Group()
+		kvs := make([]log.KeyValue, 0, len(group))
+		for _, a := range group {
+			kvs = append(kvs, log.KeyValue{Key: a.Key, Value: logValue(a.Value)})
+		}
+		return log.MapValue(kvs...)
+	case slog.KindString:
+		return log.StringValue(v.String())
+	case slog.KindInt64:
+		return log.Int64Value(v.Int64())
+	case slog.KindUint64:
+		return log.Int64Value(int64(v.Uint64()))
+	case slog.KindFloat64:
+		return log.Float64Value(v.Float64())
+	case slog.KindBool:
+		return log.BoolValue(v.Bool())
+	case slog.KindDuration:
+		return log.StringValue(v.Duration().String())
+	case slog.KindTime:
+		return log.StringValue(v.Time().Format(time.RFC3339Nano))
+	default:
+		if b, ok := v.Any().([]byte); ok {
+			return log.BytesValue(b)
+		}
+		return log.StringValue(anyString(v.Any()))
+	}
+}
@@ -0,0 +1,122 @@
+package zotel
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/logtest"
+
+	"github.com/icefed/zlog"
+)
+
+func TestSeverity(t *testing.T) {
+	tests := []struct {
+		level    slog.Level
+		expected log.Severity
+	}{
+		{level: slog.LevelDebug, expected: log.SeverityDebug},
+		{level: slog.LevelDebug + 1, expected: log.SeverityDebug2},
+		{level: slog.LevelInfo, expected: log.SeverityInfo},
+		{level: slog.LevelWarn, expected: log.SeverityWarn},
+		{level: slog.LevelError, expected: log.SeverityError},
+		{level: slog.LevelError + 4, expected: log.SeverityFatal},
+		{level: slog.LevelDebug - 100, expected: log.SeverityTrace1},
+		{level: slog.LevelError + 100, expected: log.SeverityFatal4},
+	}
+	for _, test := range tests {
+		if got := Severity(test.level); got != test.expected {
+			t.Errorf("level %v: got %v, want %v", test.level, got, test.expected)
+		}
+	}
+}
+
+func TestLogHandler(t *testing.T) {
+	recorder := logtest.NewRecorder()
+	h := NewLogHandler(recorder, "app", nil)
+	if h.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("debug should be disabled")
+	}
+
+	ctx := zlog.NewContext(context.Background(), "request_id", "abc")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	logger := zlog.New(h).With("k", "v")
+	logger.DebugContext(ctx, "debug")
+	r := slog.NewRecord(now, slog.LevelWarn, "warn", 0)
+	r.AddAttrs(slog.Int("a", 1), slog.Group("b", slog.Bool("c", true), slog.Duration("d", time.Second)), slog.Any("bytes", []byte("x")))
+	if err := logger.WithGroup("g").Handler().Handle(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+	logger.Named("sub").InfoContext(ctx, "sub")
+
+	scopes := recorder.Result()
+	records := map[string][]string{}
+	for _, scope := range scopes {
+		for _, record := range scope.Records {
+			records[scope.Name] = append(records[scope.Name], record.Body().AsString())
+		}
+	}
+	if len(records["app"]) != 1 || records["app"][0] != "warn" {
+		t.Fatalf("got records %v of app, want [warn]", records["app"])
+	}
+	if len(records["sub"]) != 1 || records["sub"][0] != "sub" {
+		t.Fatalf("got records %v of sub, want [sub]", records["sub"])
+	}
+
+	var record log.Record
+	for _, scope := range scopes {
+		if scope.Name == "app" {
+			record = scope.Records[0]
+		}
+	}
+	if !record.Timestamp().Equal(now) {
+		t.Errorf("got timestamp %v, want %v", record.Timestamp(), now)
+	}
+	if record.ObservedTimestamp().IsZero() {
+		t.Error("observed timestamp should not be zero")
+	}
+	if record.Severity() != log.SeverityWarn || record.SeverityText() != "WARN" {
+		t.Errorf("got severity %v %s, want WARN", record.Severity(), record.SeverityText())
+	}
+	expected := log.MapValue(
+		log.String("k", "v"),
+		log.Map("g",
+			log.String("request_id", "abc"),
+			log.Int64("a", 1),
+			log.Map("b", log.Bool("c", true), log.String("d", "1s")),
+			log.Bytes("bytes", []byte("x")),
+		),
+	)
+	var kvs []log.KeyValue
+	record.WalkAttributes(func(kv log.KeyValue) bool {
+		kvs = append(kvs, kv)
+		return true
+	})
+	if got := log.MapValue(kvs...); !got.Equal(expected) {
+		t.Errorf("got attributes %v, want %v", got, expected)
+	}
+}
+
+func TestLogHandlerSeverityText(t *testing.T) {
+	recorder := logtest.NewRecorder()
+	h := NewLogHandler(recorder, "app", zlog.LevelTrace)
+	levels := []slog.Level{zlog.LevelTrace, zlog.LevelNotice, zlog.LevelCritical, zlog.LevelPanic, zlog.LevelFatal}
+	for _, level := range levels {
+		if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), level, "msg", 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got []string
+	for _, scope := range recorder.Result() {
+		for _, record := range scope.Records {
+			got = append(got, record.SeverityText())
+		}
+	}
+	expected := []string{"TRACE", "NOTICE", "CRITICAL", "PANIC", "FATAL"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("got severity texts %v, want %v", got, expected)
+	}
+}
@@ -0,0 +1,186 @@
+package zotel
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/icefed/zlog"
+	"github.com/icefed/zlog/internal/groupattrs"
+)
+
+var (
+	_ slog.Handler      = (*SpanEventHandler)(nil)
+	_ zlog.NamedHandler = (*SpanEventHandler)(nil)
+	_ zlog.Syncer       = (*SpanEventHandler)(nil)
+)
+
+// SpanEventHandler implements the slog.Handler interface, it adds the records as
+// events to the recording span in the context, then passes the records to the next
+// handler. The event name is the message, the attributes are the level named by
+// zlog.LevelString as "level", the logger name as "logger", and the attributes of
+// the record with the keys qualified by the group names, e.g. "request.method".
+type SpanEventHandler struct {
+	next  slog.Handler
+	level slog.Leveler
+	// errorStatus sets the status of the span to error for the records at slog.LevelError or above.
+	errorStatus bool
+
+	name  string
+	attrs groupattrs.Attrs
+}
+
+// NewSpanEventHandler creates a SpanEventHandler, records at level or above are added
+// as span events, slog.LevelInfo is used if level is nil. next can be nil if the
+// records are only added to the spans.
+func NewSpanEventHandler(next slog.Handler, level slog.Leveler) *SpanEventHandler {
+	if level == nil {
+		level = slog.LevelInfo
+	}
+	return &SpanEventHandler{
+		next:  next,
+		level: level,
+	}
+}
+
+// WithErrorStatus returns a new handler that sets the status of the span to error
+// with the message for the records at slog.LevelError or above.
+func (h *SpanEventHandler) WithErrorStatus(enabled bool) *SpanEventHandler {
+	newHandler := *h
+	newHandler.errorStatus = enabled
+	return &newHandler
+}
+
+// Enabled reports whether the records at the level are added as span events,
+// or handled by the next handler.
+// https://pkg.go.dev/log/slog#Handler
+func (h *SpanEventHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if level >= h.level.Level() {
+		return true
+	}
+	return h.next != nil && h.next.Enabled(ctx, level)
+}
+
+// Handle adds the record as an event to the span in ctx, and calls the next handler.
+// https://pkg.go.dev/log/slog#Handler
+func (h *SpanEventHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= h.level.Level() {
+		if span := trace.SpanFromContext(ctx); span.IsRecording() {
+			attrs := make([]attribute.KeyValue, 0, r.NumAttrs()+2)
+			attrs = append(attrs, attribute.String("level", zlog.LevelString(r.Level)))
+			if h.name != "" {
+				attrs = append(attrs, attribute.String("logger", h.name))
+			}
+			for _, a := range h.attrs.Resolve(zlog.ContextAttrs(ctx), r) {
+				attrs = appendAttribute(attrs, "", a)
+			}
+			opts := []trace.EventOption{trace.WithAttributes(attrs...)}
+			if !r.Time.IsZero() {
+				opts = append(opts, trace.WithTimestamp(r.Time))
+			}
+			span.AddEvent(r.Message, opts...)
+			if h.errorStatus && r.Level >= slog.LevelError {
+				span.SetStatus(codes.Error, r.Message)
+			}
+		}
+	}
+	if h.next != nil && h.next.Enabled(ctx, r.Level) {
+		return h.next.Handle(ctx, r)
+	}
+	return nil
+}
+
+// WithAttrs implements the slog.Handler WithAttrs method.
+// https://pkg.go.dev/log/slog#Handler
+func (h *SpanEventHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	newHandler := *h
+	newHandler.attrs = h.attrs.WithAttrs(attrs)
+	if h.next != nil {
+		newHandler.next = h.next.WithAttrs(attrs)
+	}
+	return &newHandler
+}
+
+// WithGroup implements the slog.Handler WithGroup method.
+// https://pkg.go.dev/log/slog#Handler
+func (h *SpanEventHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	newHandler := *h
+	newHandler.attrs = h.attrs.WithGroup(name)
+	if h.next != nil {
+		newHandler.next = h.next.WithGroup(name)
+	}
+	return &newHandler
+}
+
+// WithName implements the zlog.NamedHandler interface, the name is added as the
+// "logger" attribute of events, and passed to the next handler if it implements
+// zlog.NamedHandler.
+func (h *SpanEventHandler) WithName(name string) slog.Handler {
+	newHandler := *h
+	newHandler.name = name
+	if nh, ok := h.next.(zlog.NamedHandler); ok {
+		newHandler.next = nh.WithName(name)
+	}
+	return &newHandler
+}
+
+// Sync implements the zlog.Syncer interface, it calls Sync of the next handler.
+func (h *SpanEventHandler) Sync() error {
+	if s, ok := h.next.(zlog.Syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// appendAttribute appends the attribute as span attributes, attributes in groups
+// are flattened with the keys qualified by the group names.
+func appendAttribute(attrs []attribute.KeyValue, prefix string, a slog.Attr) []attribute.KeyValue {
+	key := prefix + a.Key
+	v := a.Value
+	switch v.Kind() {
+	case slog.KindGroup:
+		for _, ga := range v.Group() {
+			attrs = appendAttribute(attrs, key+".", ga)
+		}
+		return attrs
+	case slog.KindString:
+		return append(attrs, attribute.String(key, v.String()))
+	case slog.KindInt64:
+		return append(attrs, attribute.Int64(key, v.Int64()))
+	case slog.KindUint64:
+		return append(attrs, attribute.Int64(key, int64(v.Uint64())))
+	case slog.KindFloat64:
+		return append(attrs, attribute.Float64(key, v.Float64()))
+	case slog.KindBool:
+		return append(attrs, attribute.Bool(key, v.Bool()))
+	case slog.KindDuration:
+		return append(attrs, attribute.String(key, v.Duration().String()))
+	case slog.KindTime:
+		return append(attrs, attribute.String(key, v.Time().Format(time.RFC3339Nano)))
+	default:
+		return append(attrs, attribute.String(key, anyString(v.Any())))
+	}
+}
+
+// anyString returns the string of the value, errors are formatted by their messages.
+func anyString(v any) string {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprintf("%+v", v)
+	}
+}
@@ -0,0 +1,141 @@
+package zotel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/icefed/zlog"
+)
+
+func TestSpanEventHandler(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "span")
+	ctx = zlog.NewContext(ctx, "request_id", "abc")
+
+	buf := bytes.NewBuffer(nil)
+	next := zlog.NewTextHandler(&zlog.Config{
+		HandlerOptions: slog.HandlerOptions{
+			Level: slog.LevelDebug,
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		},
+		Writer: buf,
+	})
+	h := NewSpanEventHandler(next, nil).WithErrorStatus(true)
+	log := zlog.New(h).Named("svc").With("k", "v")
+	log.DebugContext(ctx, "debug")
+	log.WithGroup("g").InfoContext(ctx, "info", "a", 1, slog.Group("b", "c", true))
+	log.Log(ctx, zlog.LevelCritical, "error", "err", errors.New("failed"))
+	// no span in the context
+	log.Info("no span")
+	span.End()
+
+	expectedLogs := `level=DEBUG logger=svc msg=debug k=v request_id=abc
+level=INFO logger=svc msg=info k=v g.request_id=abc g.a=1 g.b.c=true
+level=CRITICAL logger=svc msg=error k=v request_id=abc err=failed
+level=INFO logger=svc msg="no span" k=v
+`
+	if got := buf.String(); got != expectedLogs {
+		t.Errorf("got %s, want %s", got, expectedLogs)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	events := spans[0].Events()
+	expectedEvents := []struct {
+		name  string
+		attrs []attribute.KeyValue
+	}{
+		{
+			name: "info",
+			attrs: []attribute.KeyValue{
+				attribute.String("level", "INFO"),
+				attribute.String("logger", "svc"),
+				attribute.String("k", "v"),
+				attribute.String("g.request_id", "abc"),
+				attribute.Int64("g.a", 1),
+				attribute.Bool("g.b.c", true),
+			},
+		}, {
+			name: "error",
+			attrs: []attribute.KeyValue{
+				attribute.String("level", "CRITICAL"),
+				attribute.String("logger", "svc"),
+				attribute.String("k", "v"),
+				attribute.String("request_id", "abc"),
+				attribute.String("err", "failed"),
+			},
+		},
+	}
+	if len(events) != len(expectedEvents) {
+		t.Fatalf("got %d events, want %d", len(events), len(expectedEvents))
+	}
+	for i, expected := range expectedEvents {
+		if events[i].Name != expected.name {
+			t.Errorf("got event %s, want %s", events[i].Name, expected.name)
+		}
+		if got := attribute.NewSet(events[i].Attributes...); !got.Equals(ptr(attribute.NewSet(expected.attrs...))) {
+			t.Errorf("got attributes %v, want %v", events[i].Attributes, expected.attrs)
+		}
+		if events[i].Time.IsZero() {
+			t.Error("event time should not be zero")
+		}
+	}
+	if status := spans[0].Status(); status.Code != codes.Error || status.Description != "error" {
+		t.Errorf("got status %v, want error", status)
+	}
+}
+
+func TestSpanEventHandlerWithoutNext(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "span")
+
+	h := NewSpanEventHandler(nil, slog.LevelWarn)
+	if h.Enabled(ctx, slog.LevelInfo) {
+		t.Error("info should be disabled")
+	}
+	if !h.Enabled(ctx, slog.LevelWarn) {
+		t.Error("warn should be enabled")
+	}
+	log := zlog.New(h)
+	log.WarnContext(ctx, "warn")
+	log.ErrorContext(ctx, "error")
+	if err := log.Sync(); err != nil {
+		t.Errorf("sync failed: %v", err)
+	}
+	span.End()
+
+	events := recorder.Ended()[0].Events()
+	var names []string
+	for _, e := range events {
+		names = append(names, e.Name)
+	}
+	if got := strings.Join(names, ","); got != "warn,error" {
+		t.Errorf("got %s, want warn,error", got)
+	}
+	// error status is not set by default
+	if status := recorder.Ended()[0].Status(); status.Code != codes.Unset {
+		t.Errorf("got status %v, want unset", status)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}